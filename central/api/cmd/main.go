@@ -1,10 +1,15 @@
 package main
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -14,18 +19,47 @@ import (
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/authn"
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/observability"
 )
 
 // NATS subjects
 const (
 	SubjectScansNew = "scans.new"
+
+	SubjectOrchestratorJobsGet    = "orchestrator.jobs.get"    // Request/reply: status de um job
+	SubjectOrchestratorProbesList = "orchestrator.probes.list" // Request/reply: listagem de probes
+
+	SubjectReportsGet   = "reports.get"   // Request/reply: metadados + XML de um relatório
+	SubjectReportsList  = "reports.list"  // Request/reply: listagem paginada de relatórios
+	SubjectReportsStats = "reports.stats" // Request/reply: agregados de telemetria de scans
+
+	orchestratorRequestTimeout = 2 * time.Second
 )
 
+// errorEnvelope é o corpo de erro estruturado devolvido pela API quando uma
+// requisição falha, permitindo ao cliente distinguir "job desconhecido" de
+// "orchestrator inacessível" pelo campo Code.
+type errorEnvelope struct {
+	Success bool   `json:"success"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorEnvelope{Code: code, Message: message})
+}
+
 // ScanRequest representa uma requisição de scan
 type ScanRequest struct {
-	Type   string `json:"type"`   // "full" ou "directed"
-	Target string `json:"target"` // IP, range ou hostname
-	Ports  []int  `json:"ports,omitempty"`
+	Type         string   `json:"type"`   // "full" ou "directed"
+	Target       string   `json:"target"` // IP isolado ou range CIDR; hostnames não são aceitos
+	Ports        []int    `json:"ports,omitempty"`
+	RequiredTags []string `json:"required_tags,omitempty"` // tags que o probe escolhido precisa ter, ex. "pci"
+	Affinity     string   `json:"affinity,omitempty"`      // código de localização preferido, ex. "eu-west"
 }
 
 // ScanResponse resposta ao criar scan
@@ -38,6 +72,7 @@ type ScanResponse struct {
 // APIServer servidor da API
 type APIServer struct {
 	nc       *nats.Conn
+	js       nats.JetStreamContext
 	apiToken string
 }
 
@@ -81,6 +116,11 @@ func main() {
 
 	log.Info().Str("url", natsURL).Msg("Connected to NATS")
 
+	js, err := nc.JetStream()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to get JetStream context")
+	}
+
 	apiToken := os.Getenv("API_TOKEN")
 	if apiToken == "" {
 		log.Warn().Msg("API_TOKEN not set, authentication disabled")
@@ -88,6 +128,7 @@ func main() {
 
 	server := &APIServer{
 		nc:       nc,
+		js:       js,
 		apiToken: apiToken,
 	}
 
@@ -99,6 +140,13 @@ func main() {
 
 	// Routes
 	r.Get("/health", server.handleHealth)
+	observability.Mount(r)
+	observability.MountReady(r, func() error {
+		if nc.Status() != nats.CONNECTED {
+			return fmt.Errorf("NATS connection is %s, not CONNECTED", nc.Status())
+		}
+		return nil
+	})
 
 	r.Route("/api/v1", func(r chi.Router) {
 		// Auth middleware
@@ -107,8 +155,11 @@ func main() {
 		}
 
 		r.Post("/scans", server.handleCreateScan)
+		r.Get("/scans", server.handleListScans)
 		r.Get("/scans/{jobID}", server.handleGetScan)
+		r.Get("/scans/{jobID}/report", server.handleGetReport)
 		r.Get("/probes", server.handleListProbes)
+		r.Get("/stats", server.handleStats)
 	})
 
 	// Start server
@@ -136,17 +187,12 @@ func main() {
 // authMiddleware valida token de API
 func (s *APIServer) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := r.Header.Get("Authorization")
-		if token == "" {
+		token, err := authn.ExtractBearerToken(r.Header.Get("Authorization"))
+		if err != nil {
 			http.Error(w, `{"error":"missing authorization header"}`, http.StatusUnauthorized)
 			return
 		}
 
-		// Expect: Bearer <token>
-		if len(token) > 7 && token[:7] == "Bearer " {
-			token = token[7:]
-		}
-
 		if token != s.apiToken {
 			http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
 			return
@@ -176,6 +222,14 @@ func (s *APIServer) handleCreateScan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// authn.Claims.AllowsTarget só entende IP/CIDR, então um hostname nunca
+	// seria autorizado para nenhum probe e o job ficaria preso em
+	// scans.pending indefinidamente; rejeita aqui em vez de deixar girar.
+	if _, _, err := net.ParseCIDR(req.Target); err != nil && net.ParseIP(req.Target) == nil {
+		http.Error(w, `{"error":"target must be an IP address or CIDR range, hostnames are not supported"}`, http.StatusBadRequest)
+		return
+	}
+
 	if req.Type == "" {
 		req.Type = "full"
 	}
@@ -192,24 +246,32 @@ func (s *APIServer) handleCreateScan(w http.ResponseWriter, r *http.Request) {
 
 	// Create job
 	job := struct {
-		JobID     string    `json:"job_id"`
-		Type      string    `json:"type"`
-		Target    string    `json:"target"`
-		Ports     []int     `json:"ports,omitempty"`
-		Status    string    `json:"status"`
-		CreatedAt time.Time `json:"created_at"`
+		JobID        string    `json:"job_id"`
+		Type         string    `json:"type"`
+		Target       string    `json:"target"`
+		Ports        []int     `json:"ports,omitempty"`
+		RequiredTags []string  `json:"required_tags,omitempty"`
+		Affinity     string    `json:"affinity,omitempty"`
+		Status       string    `json:"status"`
+		CreatedAt    time.Time `json:"created_at"`
 	}{
-		JobID:     uuid.New().String(),
-		Type:      req.Type,
-		Target:    req.Target,
-		Ports:     req.Ports,
-		Status:    "pending",
-		CreatedAt: time.Now(),
+		JobID:        uuid.New().String(),
+		Type:         req.Type,
+		Target:       req.Target,
+		Ports:        req.Ports,
+		RequiredTags: req.RequiredTags,
+		Affinity:     req.Affinity,
+		Status:       "pending",
+		CreatedAt:    time.Now(),
 	}
 
-	// Publish to NATS
+	// Publish to NATS via JetStream, com Msg-Id para deduplicação em caso de retry do cliente
 	data, _ := json.Marshal(job)
-	if err := s.nc.Publish(SubjectScansNew, data); err != nil {
+	msg := nats.NewMsg(SubjectScansNew)
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, job.JobID)
+
+	if _, err := s.js.PublishMsg(msg); err != nil {
 		log.Error().Err(err).Msg("Failed to publish scan job")
 		http.Error(w, `{"error":"failed to queue scan"}`, http.StatusInternalServerError)
 		return
@@ -219,6 +281,8 @@ func (s *APIServer) handleCreateScan(w http.ResponseWriter, r *http.Request) {
 		Str("job_id", job.JobID).
 		Str("type", job.Type).
 		Str("target", job.Target).
+		Strs("required_tags", job.RequiredTags).
+		Str("affinity", job.Affinity).
 		Msg("Scan job created")
 
 	// Respond
@@ -231,26 +295,178 @@ func (s *APIServer) handleCreateScan(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleGetScan obtém status de um scan
+// handleGetScan obtém status de um scan consultando o orchestrator via
+// request/reply em orchestrator.jobs.get.
 func (s *APIServer) handleGetScan(w http.ResponseWriter, r *http.Request) {
 	jobID := chi.URLParam(r, "jobID")
 
-	// TODO: implementar lookup real via NATS request/reply
-	// Por enquanto, retorna placeholder
+	reqData, _ := json.Marshal(map[string]string{"job_id": jobID})
+	resp, err := s.nc.Request(SubjectOrchestratorJobsGet, reqData, orchestratorRequestTimeout)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Failed to query orchestrator for job status")
+		writeError(w, http.StatusGatewayTimeout, "orchestrator_unreachable", "failed to reach orchestrator for job status")
+		return
+	}
+
+	var envelope struct {
+		Success bool            `json:"success"`
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Job     json.RawMessage `json:"job"`
+	}
+	if err := json.Unmarshal(resp.Data, &envelope); err != nil {
+		log.Error().Err(err).Msg("Failed to parse orchestrator job status response")
+		writeError(w, http.StatusInternalServerError, "invalid_response", "failed to parse orchestrator response")
+		return
+	}
+
+	if !envelope.Success {
+		status := http.StatusBadGateway
+		if envelope.Code == "not_found" {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, envelope.Code, envelope.Message)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"job_id": jobID,
-		"status": "unknown",
-		"note":   "status lookup not yet implemented",
-	})
+	w.Write(envelope.Job)
 }
 
-// handleListProbes lista probes disponíveis
+// handleListProbes lista probes disponíveis consultando o orchestrator via
+// request/reply em orchestrator.probes.list, opcionalmente filtrado por
+// ?status= e ?location=.
 func (s *APIServer) handleListProbes(w http.ResponseWriter, r *http.Request) {
-	// TODO: implementar via NATS request/reply ao orchestrator
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"probes": []interface{}{},
-		"note":   "probe listing not yet implemented",
+	reqData, _ := json.Marshal(map[string]string{
+		"status":   r.URL.Query().Get("status"),
+		"location": r.URL.Query().Get("location"),
 	})
+
+	resp, err := s.nc.Request(SubjectOrchestratorProbesList, reqData, orchestratorRequestTimeout)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query orchestrator for probe list")
+		writeError(w, http.StatusGatewayTimeout, "orchestrator_unreachable", "failed to reach orchestrator for probe list")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp.Data)
+}
+
+// handleGetReport serve o relatório de um scan consultando o webhook via
+// request/reply em reports.get. Honra "Accept: application/xml" para
+// devolver o XML bruto; qualquer outro Accept devolve o resumo em JSON.
+func (s *APIServer) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	jobID := chi.URLParam(r, "jobID")
+
+	reqData, _ := json.Marshal(map[string]string{"job_id": jobID})
+	resp, err := s.nc.Request(SubjectReportsGet, reqData, orchestratorRequestTimeout)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Failed to query webhook for report")
+		writeError(w, http.StatusGatewayTimeout, "webhook_unreachable", "failed to reach webhook for report")
+		return
+	}
+
+	var envelope struct {
+		Success   bool            `json:"success"`
+		Code      string          `json:"code"`
+		Message   string          `json:"message"`
+		Meta      json.RawMessage `json:"meta"`
+		ReportXML string          `json:"report_xml"`
+	}
+	if err := json.Unmarshal(resp.Data, &envelope); err != nil {
+		log.Error().Err(err).Msg("Failed to parse report response")
+		writeError(w, http.StatusInternalServerError, "invalid_response", "failed to parse webhook response")
+		return
+	}
+
+	if !envelope.Success {
+		status := http.StatusBadGateway
+		if envelope.Code == "not_found" {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, envelope.Code, envelope.Message)
+		return
+	}
+
+	if isXMLAccept(r.Header.Get("Accept")) {
+		xmlBytes, err := base64.StdEncoding.DecodeString(envelope.ReportXML)
+		if err != nil {
+			log.Error().Err(err).Str("job_id", jobID).Msg("Failed to decode report XML")
+			writeError(w, http.StatusInternalServerError, "invalid_response", "failed to decode report")
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(xmlBytes)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(envelope.Meta)
+}
+
+// isXMLAccept reporta se accept pede o XML bruto do relatório. Usa substring
+// em vez de igualdade exata porque um client HTTP bem-comportado pode enviar
+// um Accept combinado/ponderado, ex. "application/xml, */*;q=0.8".
+func isXMLAccept(accept string) bool {
+	return strings.Contains(accept, "application/xml")
+}
+
+// parseSinceParam interpreta o parâmetro ?since= de handleListScans: vazio
+// não filtra por data, qualquer outro valor precisa ser RFC3339.
+func parseSinceParam(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
+
+// handleListScans lista scans concluídos consultando o webhook via
+// request/reply em reports.list, com paginação e filtros opcionais
+// ?since= (RFC3339), ?status=, ?limit= e ?offset=.
+func (s *APIServer) handleListScans(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	since, err := parseSinceParam(query.Get("since"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "since must be an RFC3339 timestamp")
+		return
+	}
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	reqData, _ := json.Marshal(struct {
+		Since  time.Time `json:"since"`
+		Status string    `json:"status"`
+		Limit  int       `json:"limit"`
+		Offset int       `json:"offset"`
+	}{Since: since, Status: query.Get("status"), Limit: limit, Offset: offset})
+
+	resp, err := s.nc.Request(SubjectReportsList, reqData, orchestratorRequestTimeout)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query webhook for scan list")
+		writeError(w, http.StatusGatewayTimeout, "webhook_unreachable", "failed to reach webhook for scan list")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp.Data)
+}
+
+// handleStats devolve agregados de telemetria de scans consultando o webhook
+// via request/reply em reports.stats, sobre a janela ?window= ("24h",
+// default, ou "7d").
+func (s *APIServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	reqData, _ := json.Marshal(map[string]string{"window": r.URL.Query().Get("window")})
+
+	resp, err := s.nc.Request(SubjectReportsStats, reqData, orchestratorRequestTimeout)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to query webhook for stats")
+		writeError(w, http.StatusGatewayTimeout, "webhook_unreachable", "failed to reach webhook for stats")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp.Data)
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// handleCreateScan's validation branches all return before ever touching
+// s.js, so they're exercisable with a zero-value APIServer and no NATS
+// connection.
+func TestHandleCreateScan_Validation(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"missing target", `{"type":"full"}`, http.StatusBadRequest},
+		{"hostname target rejected", `{"target":"scanme.example.com"}`, http.StatusBadRequest},
+		{"invalid json", `not json`, http.StatusBadRequest},
+		{"invalid type", `{"target":"10.0.0.1","type":"quick"}`, http.StatusBadRequest},
+		{"directed without ports", `{"target":"10.0.0.1","type":"directed"}`, http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		s := &APIServer{}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/scans", bytes.NewBufferString(c.body))
+		w := httptest.NewRecorder()
+
+		s.handleCreateScan(w, req)
+
+		if w.Code != c.wantStatus {
+			t.Errorf("%s: expected status %d, got %d (body %q)", c.name, c.wantStatus, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestIsXMLAccept(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   bool
+	}{
+		{"application/xml", true},
+		{"application/xml, */*;q=0.8", true},
+		{"text/xml", false},
+		{"application/json", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if got := isXMLAccept(c.accept); got != c.want {
+			t.Errorf("isXMLAccept(%q) = %v, want %v", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestParseSinceParam(t *testing.T) {
+	got, err := parseSinceParam("")
+	if err != nil || !got.IsZero() {
+		t.Fatalf("expected zero time and no error for empty input, got (%v, %v)", got, err)
+	}
+
+	want := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	got, err = parseSinceParam("2026-01-02T15:04:05Z")
+	if err != nil || !got.Equal(want) {
+		t.Fatalf("expected (%v, nil), got (%v, %v)", want, got, err)
+	}
+
+	if _, err := parseSinceParam("not-a-timestamp"); err == nil {
+		t.Fatal("expected an error for a non-RFC3339 timestamp")
+	}
+}
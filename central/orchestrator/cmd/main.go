@@ -1,16 +1,23 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/go-chi/chi/v5"
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/authn"
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/observability"
 )
 
 // Subjects NATS
@@ -19,37 +26,87 @@ const (
 	SubjectScansPending   = "scans.pending"   // Jobs aguardando probe
 	SubjectScansAssigned  = "scans.assigned"  // Jobs atribuídos
 	SubjectScansCompleted = "scans.completed" // Jobs finalizados
+	SubjectScansDead      = "scans.dead"      // Jobs que excederam MaxAttempts
 	SubjectProbesStatus   = "probes.status"   // Heartbeat dos probes
 	SubjectProbesRegister = "probes.register" // Registro de probes
+	SubjectProbesEvicted  = "probes.evicted"  // Probe removido por timeout de heartbeat
+
+	SubjectOrchestratorJobsGet    = "orchestrator.jobs.get"    // Request/reply: status de um job, usado pela API
+	SubjectOrchestratorProbesList = "orchestrator.probes.list" // Request/reply: listagem de probes, usado pela API
+)
+
+// jetStream é o subconjunto de nats.JetStreamContext usado pelo Orchestrator,
+// extraído para permitir testar o watchdog com um fake em memória.
+type jetStream interface {
+	Publish(subj string, data []byte, opts ...nats.PubOpt) (*nats.PubAck, error)
+	PullSubscribe(subj, durable string, opts ...nats.SubOpt) (*nats.Subscription, error)
+	AddStream(cfg *nats.StreamConfig, opts ...nats.JSOpt) (*nats.StreamInfo, error)
+	AddConsumer(stream string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+	UpdateConsumer(stream string, cfg *nats.ConsumerConfig, opts ...nats.JSOpt) (*nats.ConsumerInfo, error)
+}
+
+// Streams JetStream
+const (
+	StreamScans     = "SCANS"      // cobre scans.> com retenção work-queue
+	StreamProbeJobs = "PROBE_JOBS" // cobre probes.*.jobs, um consumer durável por probe
 )
 
 // ScanJob representa um job de scan
 type ScanJob struct {
-	JobID       string    `json:"job_id"`
-	Type        string    `json:"type"` // "full" ou "directed"
-	Target      string    `json:"target"`
-	Ports       []int     `json:"ports,omitempty"`
-	ProbeID     string    `json:"probe_id,omitempty"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"created_at"`
-	StartedAt   time.Time `json:"started_at,omitempty"`
-	CompletedAt time.Time `json:"completed_at,omitempty"`
+	JobID        string    `json:"job_id"`
+	Type         string    `json:"type"` // "full" ou "directed"
+	Target       string    `json:"target"`
+	Ports        []int     `json:"ports,omitempty"`
+	ProbeID      string    `json:"probe_id,omitempty"`
+	Status       string    `json:"status"`
+	RequiredTags []string  `json:"required_tags,omitempty"` // tags que o probe escolhido precisa ter, ex. "pci"
+	Affinity     string    `json:"affinity,omitempty"`      // código de localização preferido, ex. "eu-west"
+	Reason       string    `json:"reason,omitempty"`        // motivo de não ter sido atribuído, quando pendente
+	Attempts     int       `json:"attempts,omitempty"`      // quantas vezes o job foi (re)entregue a um probe
+	CreatedAt    time.Time `json:"created_at"`
+	StartedAt    time.Time `json:"started_at,omitempty"`
+	CompletedAt  time.Time `json:"completed_at,omitempty"`
 }
 
 // ProbeInfo representa informações de um probe
 type ProbeInfo struct {
 	ProbeID       string    `json:"probe_id"`
 	Location      string    `json:"location"`
-	Status        string    `json:"status"` // online, offline, busy
+	Status        string    `json:"status"` // online, offline
 	LastHeartbeat time.Time `json:"last_heartbeat"`
-	CurrentJob    string    `json:"current_job,omitempty"`
+
+	Capacity          int      `json:"capacity"`
+	InFlight          int      `json:"in_flight"`
+	ActiveJobs        []string `json:"active_jobs,omitempty"` // job IDs atualmente em execução no probe
+	Tags              []string `json:"tags,omitempty"`        // ex. "internal", "dmz", "pci"
+	AvgScanDurationMs float64  `json:"avg_scan_duration_ms"`
+	SuccessRate       float64  `json:"success_rate"`
+
+	// AllowedCIDRs vem das claims do JWT de registro, não do payload do probe.
+	AllowedCIDRs []string `json:"-"`
+	// completedScans é usado para atualizar a média móvel de duração/sucesso.
+	completedScans int
 }
 
 // Orchestrator gerencia distribuição de scans
 type Orchestrator struct {
-	nc     *nats.Conn
+	nc        *nats.Conn
+	js        jetStream
+	jwtPubKey ed25519.PublicKey
+	scheduler *Scheduler
+	metrics   *observability.Metrics
+
+	probeTimeout time.Duration // heartbeats mais antigos que isso evictam o probe
+	maxAttempts  int           // tentativas de entrega antes de mandar o job para scans.dead
+	now          func() time.Time
+
+	mu     sync.Mutex
 	probes map[string]*ProbeInfo
 	jobs   map[string]*ScanJob
+
+	// probeFreed sinaliza o drainer de scans.pending sempre que um probe se
+	// torna disponível (completou um job ou acabou de se registrar).
+	probeFreed chan struct{}
 }
 
 func main() {
@@ -92,19 +149,66 @@ func main() {
 
 	log.Info().Str("url", natsURL).Msg("Connected to NATS")
 
-	orch := &Orchestrator{
-		nc:     nc,
-		probes: make(map[string]*ProbeInfo),
-		jobs:   make(map[string]*ScanJob),
+	js, err := nc.JetStream()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to get JetStream context")
 	}
 
-	// Subscribe to new scans
-	_, err = nc.Subscribe(SubjectScansNew, orch.handleNewScan)
+	jwtPubKeyPath := os.Getenv("NATS_JWT_PUBKEY")
+	if jwtPubKeyPath == "" {
+		log.Fatal().Msg("NATS_JWT_PUBKEY is required to authenticate probes")
+	}
+	jwtPubKey, err := authn.LoadPublicKey(jwtPubKeyPath)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to subscribe to scans.new")
+		log.Fatal().Err(err).Msg("Failed to load probe JWT public key")
+	}
+
+	orch := &Orchestrator{
+		nc:           nc,
+		js:           js,
+		jwtPubKey:    jwtPubKey,
+		scheduler:    NewScheduler(),
+		metrics:      observability.NewMetrics(),
+		probeTimeout: probeTimeoutFromEnv(),
+		maxAttempts:  maxAttemptsFromEnv(),
+		now:          time.Now,
+		probes:       make(map[string]*ProbeInfo),
+		jobs:         make(map[string]*ScanJob),
+		probeFreed:   make(chan struct{}, 1),
+	}
+
+	if err := orch.setupStreams(); err != nil {
+		log.Fatal().Err(err).Msg("Failed to setup JetStream streams")
 	}
 
-	// Subscribe to probe registrations
+	// Admin listener: métricas e readiness não ficam atrás da autenticação de
+	// probes, por isso vivem em uma porta HTTP separada das filas NATS.
+	adminRouter := chi.NewRouter()
+	observability.Mount(adminRouter)
+	observability.MountReady(adminRouter, func() error {
+		if nc.Status() != nats.CONNECTED {
+			return fmt.Errorf("NATS connection is %s, not CONNECTED", nc.Status())
+		}
+		orch.mu.Lock()
+		registered := len(orch.probes) > 0
+		orch.mu.Unlock()
+		if !registered {
+			return fmt.Errorf("no probes have registered yet")
+		}
+		return nil
+	})
+
+	adminPort := os.Getenv("ADMIN_PORT")
+	if adminPort == "" {
+		adminPort = "9090"
+	}
+	go func() {
+		if err := http.ListenAndServe(":"+adminPort, adminRouter); err != nil {
+			log.Fatal().Err(err).Msg("Admin listener failed")
+		}
+	}()
+
+	// Subscribe to probe registrations (metadata, não precisa de work-queue)
 	_, err = nc.Subscribe(SubjectProbesRegister, orch.handleProbeRegister)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to subscribe to probes.register")
@@ -116,11 +220,36 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to subscribe to probes.status")
 	}
 
-	// Subscribe to completed scans
-	_, err = nc.Subscribe(SubjectScansCompleted, orch.handleScanCompleted)
+	// Request/reply consultado pela API para expor status de jobs e probes
+	_, err = nc.Subscribe(SubjectOrchestratorJobsGet, orch.handleJobsGet)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to subscribe to orchestrator.jobs.get")
+	}
+	_, err = nc.Subscribe(SubjectOrchestratorProbesList, orch.handleProbesList)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to subscribe to orchestrator.probes.list")
+	}
+
+	// Pull consumers para os jobs que precisam de entrega garantida
+	newScansSub, err := js.PullSubscribe(SubjectScansNew, "orch-scans-new", nats.ManualAck())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create pull subscription for scans.new")
+	}
+	go orch.runPullLoop(newScansSub, orch.handleNewScan)
+
+	completedSub, err := js.PullSubscribe(SubjectScansCompleted, "orch-scans-completed", nats.ManualAck())
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to subscribe to scans.completed")
+		log.Fatal().Err(err).Msg("Failed to create pull subscription for scans.completed")
 	}
+	go orch.runPullLoop(completedSub, orch.handleScanCompleted)
+
+	pendingSub, err := js.PullSubscribe(SubjectScansPending, "orch-scans-pending", nats.ManualAck())
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create pull subscription for scans.pending")
+	}
+	go orch.runPendingDrainLoop(pendingSub)
+
+	go orch.runWatchdog()
 
 	log.Info().Msg("Orchestrator ready, waiting for jobs...")
 
@@ -132,20 +261,106 @@ func main() {
 	log.Info().Msg("Shutting down orchestrator")
 }
 
+// setupStreams garante que os streams de scans e filas de probes existam
+func (o *Orchestrator) setupStreams() error {
+	_, err := o.js.AddStream(&nats.StreamConfig{
+		Name:      StreamScans,
+		Subjects:  []string{"scans.>"},
+		Retention: nats.WorkQueuePolicy,
+		Storage:   nats.FileStorage,
+	})
+	if err != nil && err != nats.ErrStreamNameAlreadyInUse {
+		return err
+	}
+
+	_, err = o.js.AddStream(&nats.StreamConfig{
+		Name:      StreamProbeJobs,
+		Subjects:  []string{"probes.*.jobs"},
+		Retention: nats.WorkQueuePolicy,
+		Storage:   nats.FileStorage,
+	})
+	if err != nil && err != nats.ErrStreamNameAlreadyInUse {
+		return err
+	}
+
+	return nil
+}
+
+// ensureProbeConsumer cria o consumer durável da fila de jobs de um probe,
+// tolerante a scans longos (AckWait alto) e a probes que nunca respondem
+// (MaxDeliver limita as reentregas na fila deste mesmo probe; a reatribuição
+// a outro probe é feita pelo watchdog em requeueJob). MaxAckPending
+// é travado à capacity do probe: o Scheduler atribui até Capacity jobs
+// concorrentes (InFlight < Capacity), e o consumer precisa conseguir entregar
+// esse mesmo número de mensagens não confirmadas de uma vez, senão a entrega
+// trava em 1-por-vez independente de quantos jobs o scheduler já atribuiu.
+func (o *Orchestrator) ensureProbeConsumer(probeID string, capacity int) error {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	subject := subjectForProbe(probeID)
+	cfg := &nats.ConsumerConfig{
+		Durable:       "probe-" + probeID,
+		FilterSubject: subject,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       30 * time.Minute,
+		MaxDeliver:    3,
+		MaxAckPending: capacity,
+	}
+	if _, err := o.js.AddConsumer(StreamProbeJobs, cfg); err != nil {
+		if err != nats.ErrConsumerNameAlreadyInUse {
+			return err
+		}
+		if _, err := o.js.UpdateConsumer(StreamProbeJobs, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func subjectForProbe(probeID string) string {
+	return "probes." + probeID + ".jobs"
+}
+
+// runPullLoop busca mensagens continuamente de um pull consumer e as despacha
+// para o handler informado, até o programa ser encerrado.
+func (o *Orchestrator) runPullLoop(sub *nats.Subscription, handle func(*nats.Msg)) {
+	for {
+		msgs, err := sub.Fetch(10, nats.MaxWait(5*time.Second))
+		if err != nil {
+			if err == nats.ErrTimeout {
+				continue
+			}
+			log.Warn().Err(err).Msg("Pull consumer fetch failed")
+			continue
+		}
+
+		for _, msg := range msgs {
+			handle(msg)
+		}
+	}
+}
+
 // handleNewScan processa novos scans vindos da API
 func (o *Orchestrator) handleNewScan(msg *nats.Msg) {
 	var job ScanJob
 	if err := json.Unmarshal(msg.Data, &job); err != nil {
 		log.Error().Err(err).Msg("Failed to unmarshal scan job")
+		msg.Ack() // payload malformado, não adianta reentregar
 		return
 	}
 
-	// Assign UUID if not present
 	if job.JobID == "" {
-		job.JobID = uuid.New().String()
+		log.Error().Msg("Scan job missing job_id, dropping")
+		msg.Ack()
+		return
 	}
+
 	job.Status = "pending"
-	job.CreatedAt = time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	o.metrics.ScansCreatedTotal.WithLabelValues(job.Type).Inc()
 
 	log.Info().
 		Str("job_id", job.JobID).
@@ -153,56 +368,117 @@ func (o *Orchestrator) handleNewScan(msg *nats.Msg) {
 		Str("target", job.Target).
 		Msg("Received new scan job")
 
-	// Store job
+	o.mu.Lock()
 	o.jobs[job.JobID] = &job
+	probe, reason := o.scheduler.Select(o.probes, &job)
+	if probe != nil {
+		job.ProbeID = probe.ProbeID
+		job.Status = "assigned"
+		job.StartedAt = time.Now()
+		probe.assignJob(job.JobID)
+	}
+	o.mu.Unlock()
+	o.updateGauges()
 
-	// Find available probe
-	probe := o.findAvailableProbe()
 	if probe == nil {
-		log.Warn().Str("job_id", job.JobID).Msg("No available probe, job queued")
-		// Publish to pending queue
+		log.Warn().Str("job_id", job.JobID).Str("reason", reason).Msg("No available probe, job queued")
+		job.Reason = reason
 		data, _ := json.Marshal(job)
-		o.nc.Publish(SubjectScansPending, data)
+		if _, err := o.js.Publish(SubjectScansPending, data); err != nil {
+			log.Error().Err(err).Str("job_id", job.JobID).Msg("Failed to publish to scans.pending")
+			msg.Nak()
+			return
+		}
+		msg.Ack()
 		return
 	}
 
-	// Assign to probe
-	job.ProbeID = probe.ProbeID
-	job.Status = "assigned"
-	probe.Status = "busy"
-	probe.CurrentJob = job.JobID
+	if err := o.ensureProbeConsumer(probe.ProbeID, probe.Capacity); err != nil {
+		log.Error().Err(err).Str("probe_id", probe.ProbeID).Msg("Failed to ensure probe consumer")
+		msg.Nak()
+		return
+	}
 
 	log.Info().
 		Str("job_id", job.JobID).
 		Str("probe_id", probe.ProbeID).
 		Msg("Assigned job to probe")
 
-	// Publish to probe-specific queue
 	data, _ := json.Marshal(job)
-	o.nc.Publish("probes."+probe.ProbeID+".jobs", data)
+	if _, err := o.js.Publish(subjectForProbe(probe.ProbeID), data); err != nil {
+		log.Error().Err(err).Str("job_id", job.JobID).Msg("Failed to publish to probe queue")
+		msg.Nak()
+		return
+	}
 
-	// Also publish assignment notification
-	o.nc.Publish(SubjectScansAssigned, data)
+	if _, err := o.js.Publish(SubjectScansAssigned, data); err != nil {
+		log.Warn().Err(err).Str("job_id", job.JobID).Msg("Failed to publish scan assignment notification")
+	}
+
+	msg.Ack()
+}
+
+// authenticateProbe extrai e valida o JWT do probe a partir do header
+// Authorization da mensagem NATS, retornando suas claims.
+func (o *Orchestrator) authenticateProbe(msg *nats.Msg) (*authn.Claims, error) {
+	token, err := authn.ExtractBearerToken(msg.Header.Get("Authorization"))
+	if err != nil {
+		return nil, err
+	}
+	return authn.ParseToken(token, o.jwtPubKey)
 }
 
 // handleProbeRegister processa registro de novos probes
 func (o *Orchestrator) handleProbeRegister(msg *nats.Msg) {
-	var probe ProbeInfo
-	if err := json.Unmarshal(msg.Data, &probe); err != nil {
-		log.Error().Err(err).Msg("Failed to unmarshal probe registration")
+	claims, err := o.authenticateProbe(msg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejected probe registration: authentication failed")
+		if msg.Reply != "" {
+			msg.Respond([]byte(`{"status":"unauthorized"}`))
+		}
 		return
 	}
 
-	probe.Status = "online"
-	probe.LastHeartbeat = time.Now()
-	o.probes[probe.ProbeID] = &probe
+	// Capacidade e tags são metadados operacionais do probe, não precisam
+	// estar nas claims assinadas pelo operador.
+	var meta struct {
+		Capacity int      `json:"capacity"`
+		Tags     []string `json:"tags,omitempty"`
+	}
+	json.Unmarshal(msg.Data, &meta) // meta é opcional, payload ausente/inválido usa defaults
+
+	if meta.Capacity <= 0 {
+		meta.Capacity = 1
+	}
+
+	probe := &ProbeInfo{
+		ProbeID:       claims.ProbeID,
+		Location:      claims.Location,
+		AllowedCIDRs:  claims.AllowedCIDRs,
+		Status:        "online",
+		LastHeartbeat: time.Now(),
+		Capacity:      meta.Capacity,
+		Tags:          meta.Tags,
+		SuccessRate:   1, // otimista até que o histórico diga o contrário
+	}
+
+	o.mu.Lock()
+	o.probes[probe.ProbeID] = probe
+	o.mu.Unlock()
+	o.updateGauges()
+
+	if err := o.ensureProbeConsumer(probe.ProbeID, probe.Capacity); err != nil {
+		log.Error().Err(err).Str("probe_id", probe.ProbeID).Msg("Failed to create probe job consumer")
+	}
 
 	log.Info().
 		Str("probe_id", probe.ProbeID).
 		Str("location", probe.Location).
+		Int("capacity", probe.Capacity).
 		Msg("Probe registered")
 
-	// Reply with confirmation
+	o.signalProbeFree()
+
 	if msg.Reply != "" {
 		msg.Respond([]byte(`{"status":"registered"}`))
 	}
@@ -210,19 +486,26 @@ func (o *Orchestrator) handleProbeRegister(msg *nats.Msg) {
 
 // handleProbeStatus processa heartbeats dos probes
 func (o *Orchestrator) handleProbeStatus(msg *nats.Msg) {
-	var status struct {
-		ProbeID string `json:"probe_id"`
-		Status  string `json:"status"`
+	claims, err := o.authenticateProbe(msg)
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejected probe heartbeat: authentication failed")
+		return
 	}
 
+	var status struct {
+		Status string `json:"status"`
+	}
 	if err := json.Unmarshal(msg.Data, &status); err != nil {
 		return
 	}
 
-	if probe, ok := o.probes[status.ProbeID]; ok {
+	o.mu.Lock()
+	if probe, ok := o.probes[claims.ProbeID]; ok {
 		probe.LastHeartbeat = time.Now()
 		probe.Status = status.Status
 	}
+	o.mu.Unlock()
+	o.updateGauges()
 }
 
 // handleScanCompleted processa scans finalizados
@@ -235,6 +518,7 @@ func (o *Orchestrator) handleScanCompleted(msg *nats.Msg) {
 
 	if err := json.Unmarshal(msg.Data, &result); err != nil {
 		log.Error().Err(err).Msg("Failed to unmarshal completion")
+		msg.Ack()
 		return
 	}
 
@@ -244,25 +528,191 @@ func (o *Orchestrator) handleScanCompleted(msg *nats.Msg) {
 		Str("status", result.Status).
 		Msg("Scan completed")
 
-	// Update job status
+	o.mu.Lock()
+	var durationMs float64
+	var totalDuration time.Duration
 	if job, ok := o.jobs[result.JobID]; ok {
 		job.Status = result.Status
 		job.CompletedAt = time.Now()
+		if !job.StartedAt.IsZero() {
+			durationMs = float64(job.CompletedAt.Sub(job.StartedAt).Milliseconds())
+		}
+		if !job.CreatedAt.IsZero() {
+			totalDuration = job.CompletedAt.Sub(job.CreatedAt)
+		}
 	}
 
-	// Mark probe as available
 	if probe, ok := o.probes[result.ProbeID]; ok {
-		probe.Status = "online"
-		probe.CurrentJob = ""
+		probe.releaseJob(result.JobID)
+		probe.recordCompletion(result.Status == "completed", durationMs)
+	}
+	o.mu.Unlock()
+
+	o.metrics.ScansCompletedTotal.WithLabelValues(result.Status).Inc()
+	if totalDuration > 0 {
+		o.metrics.ScanDurationSeconds.Observe(totalDuration.Seconds())
+	}
+
+	o.signalProbeFree()
+	o.updateGauges()
+
+	msg.Ack()
+}
+
+// assignJob marca um job como em execução no probe. Deve ser chamado com o
+// mutex do Orchestrator travado.
+func (p *ProbeInfo) assignJob(jobID string) {
+	p.ActiveJobs = append(p.ActiveJobs, jobID)
+	p.InFlight = len(p.ActiveJobs)
+}
+
+// releaseJob remove um job da lista de jobs em execução do probe. Deve ser
+// chamado com o mutex do Orchestrator travado.
+func (p *ProbeInfo) releaseJob(jobID string) {
+	for i, id := range p.ActiveJobs {
+		if id == jobID {
+			p.ActiveJobs = append(p.ActiveJobs[:i], p.ActiveJobs[i+1:]...)
+			break
+		}
 	}
+	p.InFlight = len(p.ActiveJobs)
 }
 
-// findAvailableProbe encontra um probe disponível
-func (o *Orchestrator) findAvailableProbe() *ProbeInfo {
+// recordCompletion atualiza as métricas móveis do probe com o resultado mais
+// recente. Deve ser chamado com o mutex do Orchestrator travado.
+func (p *ProbeInfo) recordCompletion(success bool, durationMs float64) {
+	n := float64(p.completedScans)
+
+	successValue := 0.0
+	if success {
+		successValue = 1.0
+	}
+	p.SuccessRate = (p.SuccessRate*n + successValue) / (n + 1)
+
+	if durationMs > 0 {
+		p.AvgScanDurationMs = (p.AvgScanDurationMs*n + durationMs) / (n + 1)
+	}
+
+	p.completedScans++
+}
+
+// updateGauges recomputa os gauges de observabilidade a partir do estado
+// atual de probes e jobs. Não deve ser chamado com o mutex do Orchestrator
+// já travado.
+func (o *Orchestrator) updateGauges() {
+	o.mu.Lock()
+	var online, busy, pending float64
 	for _, probe := range o.probes {
-		if probe.Status == "online" {
-			return probe
+		if probe.Status != "online" {
+			continue
+		}
+		online++
+		if probe.InFlight > 0 {
+			busy++
 		}
 	}
-	return nil
+	for _, job := range o.jobs {
+		if job.Status == "pending" {
+			pending++
+		}
+	}
+	o.mu.Unlock()
+
+	o.metrics.ProbesOnline.Set(online)
+	o.metrics.ProbesBusy.Set(busy)
+	o.metrics.JobsPending.Set(pending)
+}
+
+// signalProbeFree acorda o drainer de scans.pending sem bloquear se já houver
+// um sinal pendente.
+func (o *Orchestrator) signalProbeFree() {
+	select {
+	case o.probeFreed <- struct{}{}:
+	default:
+	}
+}
+
+// runPendingDrainLoop reprocessa scans.pending sempre que um probe fica livre
+// ou registra, e periodicamente como rede de segurança.
+func (o *Orchestrator) runPendingDrainLoop(sub *nats.Subscription) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-o.probeFreed:
+		case <-ticker.C:
+		}
+		o.drainPending(sub)
+	}
+}
+
+// drainPending tenta reatribuir um lote de jobs pendentes aos probes
+// atualmente disponíveis, devolvendo à fila (Nak) os que ainda não qualificam.
+func (o *Orchestrator) drainPending(sub *nats.Subscription) {
+	msgs, err := sub.Fetch(10, nats.MaxWait(1*time.Second))
+	if err != nil {
+		if err != nats.ErrTimeout {
+			log.Warn().Err(err).Msg("Failed to fetch pending scans")
+		}
+		return
+	}
+
+	for _, msg := range msgs {
+		var job ScanJob
+		if err := json.Unmarshal(msg.Data, &job); err != nil {
+			msg.Ack() // payload malformado, descarta
+			continue
+		}
+
+		o.mu.Lock()
+		probe, reason := o.scheduler.Select(o.probes, &job)
+		if probe != nil {
+			job.ProbeID = probe.ProbeID
+			job.Status = "assigned"
+			job.Reason = ""
+			job.StartedAt = time.Now()
+			probe.assignJob(job.JobID)
+			if tracked, ok := o.jobs[job.JobID]; ok {
+				tracked.ProbeID = job.ProbeID
+				tracked.Status = job.Status
+				tracked.Reason = job.Reason
+				tracked.StartedAt = job.StartedAt
+			} else {
+				o.jobs[job.JobID] = &job
+			}
+		}
+		o.mu.Unlock()
+
+		if probe == nil {
+			job.Reason = reason
+			msg.Nak()
+			continue
+		}
+
+		if err := o.ensureProbeConsumer(probe.ProbeID, probe.Capacity); err != nil {
+			log.Error().Err(err).Str("probe_id", probe.ProbeID).Msg("Failed to ensure probe consumer")
+			msg.Nak()
+			continue
+		}
+
+		data, _ := json.Marshal(job)
+		if _, err := o.js.Publish(subjectForProbe(probe.ProbeID), data); err != nil {
+			log.Error().Err(err).Str("job_id", job.JobID).Msg("Failed to publish to probe queue")
+			msg.Nak()
+			continue
+		}
+		if _, err := o.js.Publish(SubjectScansAssigned, data); err != nil {
+			log.Warn().Err(err).Str("job_id", job.JobID).Msg("Failed to publish scan assignment notification")
+		}
+
+		log.Info().
+			Str("job_id", job.JobID).
+			Str("probe_id", probe.ProbeID).
+			Msg("Assigned previously pending job to probe")
+
+		msg.Ack()
+	}
+
+	o.updateGauges()
 }
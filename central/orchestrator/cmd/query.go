@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+)
+
+// handleJobsGet responde ao request/reply orchestrator.jobs.get com o estado
+// atual do job solicitado, usado pela API para expor GET /api/v1/scans/{jobID}.
+func (o *Orchestrator) handleJobsGet(msg *nats.Msg) {
+	var req struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal(msg.Data, &req); err != nil || req.JobID == "" {
+		respondError(msg, "bad_request", "job_id is required")
+		return
+	}
+
+	o.mu.Lock()
+	job, ok := o.jobs[req.JobID]
+	var jobCopy ScanJob
+	if ok {
+		jobCopy = *job
+	}
+	o.mu.Unlock()
+
+	if !ok {
+		respondError(msg, "not_found", "no job with that job_id")
+		return
+	}
+
+	data, err := json.Marshal(struct {
+		Success bool    `json:"success"`
+		Job     ScanJob `json:"job"`
+	}{Success: true, Job: jobCopy})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal job status response")
+		respondError(msg, "internal_error", "failed to marshal job status")
+		return
+	}
+	msg.Respond(data)
+}
+
+// handleProbesList responde ao request/reply orchestrator.probes.list,
+// opcionalmente filtrado por status e location, usado pela API para expor
+// GET /api/v1/probes.
+func (o *Orchestrator) handleProbesList(msg *nats.Msg) {
+	var filter struct {
+		Status   string `json:"status"`
+		Location string `json:"location"`
+	}
+	json.Unmarshal(msg.Data, &filter) // filtro é opcional, ausência vazia não filtra nada
+
+	o.mu.Lock()
+	probes := make([]ProbeInfo, 0, len(o.probes))
+	for _, p := range o.probes {
+		if filter.Status != "" && p.Status != filter.Status {
+			continue
+		}
+		if filter.Location != "" && p.Location != filter.Location {
+			continue
+		}
+		probes = append(probes, *p)
+	}
+	o.mu.Unlock()
+
+	data, err := json.Marshal(struct {
+		Success bool        `json:"success"`
+		Probes  []ProbeInfo `json:"probes"`
+	}{Success: true, Probes: probes})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal probe list response")
+		respondError(msg, "internal_error", "failed to marshal probe list")
+		return
+	}
+	msg.Respond(data)
+}
+
+// respondError envia o envelope {success:false, code, message} de resposta,
+// compartilhado pelos handlers de request/reply do orchestrator.
+func respondError(msg *nats.Msg, code, message string) {
+	data, _ := json.Marshal(struct {
+		Success bool   `json:"success"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{Success: false, Code: code, Message: message})
+	msg.Respond(data)
+}
@@ -0,0 +1,99 @@
+package main
+
+import "github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/authn"
+
+// Pesos da função de pontuação do Scheduler. w1 favorece probes com folga de
+// capacidade, w2 recompensa afinidade de localização, w3 recompensa
+// confiabilidade histórica e w4 penaliza probes historicamente mais lentos.
+const (
+	weightLoad        = 0.4
+	weightLocation    = 0.25
+	weightSuccessRate = 0.25
+	weightLatency     = 0.1
+	latencyBaselineMs = float64(10 * 60 * 1000) // 10 minutos, usado para normalizar AvgScanDurationMs
+)
+
+// Scheduler escolhe, entre os probes online, o de maior pontuação que atenda
+// às tags exigidas pelo job e cujas claims cubram o alvo.
+type Scheduler struct{}
+
+// NewScheduler cria um Scheduler sem estado próprio; toda a informação usada
+// para pontuar vem do ProbeInfo de cada probe.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Select retorna o melhor probe para o job, ou nil e um motivo legível quando
+// nenhum probe qualifica (sem capacidade livre, tags incompatíveis ou CIDR
+// fora do escopo autorizado).
+func (s *Scheduler) Select(probes map[string]*ProbeInfo, job *ScanJob) (*ProbeInfo, string) {
+	var best *ProbeInfo
+	bestScore := -1.0
+
+	for _, probe := range probes {
+		if probe.Status != "online" {
+			continue
+		}
+		if probe.Capacity > 0 && probe.InFlight >= probe.Capacity {
+			continue
+		}
+		if !hasAllTags(probe.Tags, job.RequiredTags) {
+			continue
+		}
+		claims := &authn.Claims{AllowedCIDRs: probe.AllowedCIDRs}
+		if !claims.AllowsTarget(job.Target) {
+			continue
+		}
+
+		score := s.score(probe, job)
+		if best == nil || score > bestScore {
+			best = probe
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return nil, "no probe with free capacity, matching tags, and an authorized CIDR scope"
+	}
+	return best, ""
+}
+
+func (s *Scheduler) score(probe *ProbeInfo, job *ScanJob) float64 {
+	capacity := probe.Capacity
+	if capacity <= 0 {
+		capacity = 1
+	}
+	loadScore := 1 - float64(probe.InFlight)/float64(capacity)
+
+	locationMatch := 0.0
+	if job.Affinity != "" && job.Affinity == probe.Location {
+		locationMatch = 1.0
+	}
+
+	normalizedLatency := probe.AvgScanDurationMs / latencyBaselineMs
+	if normalizedLatency > 1 {
+		normalizedLatency = 1
+	}
+
+	return weightLoad*loadScore +
+		weightLocation*locationMatch +
+		weightSuccessRate*probe.SuccessRate -
+		weightLatency*normalizedLatency
+}
+
+// hasAllTags reporta se probeTags contém todas as tags exigidas.
+func hasAllTags(probeTags, required []string) bool {
+	if len(required) == 0 {
+		return true
+	}
+	set := make(map[string]bool, len(probeTags))
+	for _, t := range probeTags {
+		set[t] = true
+	}
+	for _, r := range required {
+		if !set[r] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultProbeTimeout  = 45 * time.Second
+	defaultMaxAttempts   = 3
+	watchdogTickInterval = 5 * time.Second
+)
+
+// probeTimeoutFromEnv lê PROBE_TIMEOUT (duração Go, ex. "45s", ou segundos puros)
+// e retorna defaultProbeTimeout se ausente ou inválido.
+func probeTimeoutFromEnv() time.Duration {
+	raw := os.Getenv("PROBE_TIMEOUT")
+	if raw == "" {
+		return defaultProbeTimeout
+	}
+	if d, err := time.ParseDuration(raw); err == nil {
+		return d
+	}
+	if secs, err := strconv.Atoi(raw); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	log.Warn().Str("value", raw).Msg("Invalid PROBE_TIMEOUT, using default")
+	return defaultProbeTimeout
+}
+
+// maxAttemptsFromEnv lê MAX_ATTEMPTS e retorna defaultMaxAttempts se ausente ou inválido.
+func maxAttemptsFromEnv() int {
+	raw := os.Getenv("MAX_ATTEMPTS")
+	if raw == "" {
+		return defaultMaxAttempts
+	}
+	if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+		return n
+	}
+	log.Warn().Str("value", raw).Msg("Invalid MAX_ATTEMPTS, using default")
+	return defaultMaxAttempts
+}
+
+// runWatchdog verifica periodicamente se algum probe parou de enviar
+// heartbeat e, se sim, o evicta e redistribui seus jobs em andamento.
+func (o *Orchestrator) runWatchdog() {
+	ticker := time.NewTicker(watchdogTickInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		o.evictStalledProbes()
+	}
+}
+
+// evictStalledProbes varre os probes procurando heartbeats vencidos. Cada
+// probe evictado tem seus jobs em andamento reenfileirados ou enviados para
+// a DLQ quando excedem MaxAttempts.
+func (o *Orchestrator) evictStalledProbes() {
+	type evicted struct {
+		probeID string
+		jobIDs  []string
+	}
+
+	now := o.now()
+	var stalled []evicted
+
+	o.mu.Lock()
+	for _, probe := range o.probes {
+		if probe.Status == "offline" {
+			continue
+		}
+		if now.Sub(probe.LastHeartbeat) <= o.probeTimeout {
+			continue
+		}
+
+		jobIDs := append([]string(nil), probe.ActiveJobs...)
+		probe.Status = "offline"
+		probe.ActiveJobs = nil
+		probe.InFlight = 0
+		stalled = append(stalled, evicted{probeID: probe.ProbeID, jobIDs: jobIDs})
+	}
+	o.mu.Unlock()
+
+	for _, s := range stalled {
+		log.Warn().
+			Str("probe_id", s.probeID).
+			Dur("timeout", o.probeTimeout).
+			Msg("Probe heartbeat timed out, evicting")
+
+		o.publishProbeEvicted(s.probeID)
+		for _, jobID := range s.jobIDs {
+			o.requeueJob(jobID)
+		}
+	}
+
+	o.updateGauges()
+}
+
+// publishProbeEvicted notifica interessados de que um probe foi removido por timeout.
+func (o *Orchestrator) publishProbeEvicted(probeID string) {
+	data, _ := json.Marshal(map[string]string{"probe_id": probeID})
+	if _, err := o.js.Publish(SubjectProbesEvicted, data); err != nil {
+		log.Warn().Err(err).Str("probe_id", probeID).Msg("Failed to publish probes.evicted event")
+	}
+}
+
+// requeueJob incrementa Attempts e republica o job em scans.new, ou o manda
+// para scans.dead quando MaxAttempts é excedido.
+func (o *Orchestrator) requeueJob(jobID string) {
+	o.mu.Lock()
+	job, ok := o.jobs[jobID]
+	if !ok {
+		o.mu.Unlock()
+		return
+	}
+
+	job.Attempts++
+	job.ProbeID = ""
+	dead := job.Attempts > o.maxAttempts
+	if dead {
+		job.Status = "dead"
+	} else {
+		job.Status = "pending"
+	}
+	jobCopy := *job
+	o.mu.Unlock()
+	o.updateGauges()
+
+	data, _ := json.Marshal(jobCopy)
+
+	if dead {
+		log.Error().
+			Str("job_id", jobID).
+			Int("attempts", jobCopy.Attempts).
+			Msg("Job exceeded max attempts, sending to scans.dead")
+		if _, err := o.js.Publish(SubjectScansDead, data); err != nil {
+			log.Error().Err(err).Str("job_id", jobID).Msg("Failed to publish to scans.dead")
+		}
+		return
+	}
+
+	log.Info().
+		Str("job_id", jobID).
+		Int("attempts", jobCopy.Attempts).
+		Msg("Requeueing job from evicted probe")
+	if _, err := o.js.Publish(SubjectScansNew, data); err != nil {
+		log.Error().Err(err).Str("job_id", jobID).Msg("Failed to republish job")
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/observability"
+)
+
+// testMetrics é criado uma única vez para todo o pacote de testes, já que
+// observability.NewMetrics registra as métricas no registry global do
+// Prometheus e um segundo registro do mesmo nome causaria pânico.
+var testMetrics = observability.NewMetrics()
+
+// fakeJetStream registra as publicações feitas pelo Orchestrator sem precisar
+// de um servidor NATS real.
+type fakeJetStream struct {
+	mu        sync.Mutex
+	published map[string][][]byte
+}
+
+func newFakeJetStream() *fakeJetStream {
+	return &fakeJetStream{published: make(map[string][][]byte)}
+}
+
+func (f *fakeJetStream) Publish(subj string, data []byte, _ ...nats.PubOpt) (*nats.PubAck, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.published[subj] = append(f.published[subj], data)
+	return &nats.PubAck{}, nil
+}
+
+func (f *fakeJetStream) PullSubscribe(string, string, ...nats.SubOpt) (*nats.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeJetStream) AddStream(*nats.StreamConfig, ...nats.JSOpt) (*nats.StreamInfo, error) {
+	return nil, nil
+}
+
+func (f *fakeJetStream) AddConsumer(string, *nats.ConsumerConfig, ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	return nil, nil
+}
+
+func (f *fakeJetStream) UpdateConsumer(string, *nats.ConsumerConfig, ...nats.JSOpt) (*nats.ConsumerInfo, error) {
+	return nil, nil
+}
+
+func (f *fakeJetStream) messages(subj string) [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.published[subj]
+}
+
+func newTestOrchestrator(js *fakeJetStream, clock func() time.Time) *Orchestrator {
+	return &Orchestrator{
+		js:           js,
+		scheduler:    NewScheduler(),
+		metrics:      testMetrics,
+		probeTimeout: defaultProbeTimeout,
+		maxAttempts:  defaultMaxAttempts,
+		now:          clock,
+		probes:       make(map[string]*ProbeInfo),
+		jobs:         make(map[string]*ScanJob),
+		probeFreed:   make(chan struct{}, 1),
+	}
+}
+
+func TestEvictStalledProbes_RequeuesJobToScansNew(t *testing.T) {
+	base := time.Now()
+	js := newFakeJetStream()
+	o := newTestOrchestrator(js, func() time.Time { return base.Add(50 * time.Second) })
+
+	o.probes["probe-1"] = &ProbeInfo{
+		ProbeID:       "probe-1",
+		Status:        "online",
+		LastHeartbeat: base,
+		ActiveJobs:    []string{"job-1"},
+		InFlight:      1,
+	}
+	o.jobs["job-1"] = &ScanJob{JobID: "job-1", Status: "assigned", ProbeID: "probe-1"}
+
+	o.evictStalledProbes()
+
+	if o.probes["probe-1"].Status != "offline" {
+		t.Fatalf("expected probe to be evicted, got status %q", o.probes["probe-1"].Status)
+	}
+	if len(o.probes["probe-1"].ActiveJobs) != 0 {
+		t.Fatalf("expected probe to have no active jobs left, got %v", o.probes["probe-1"].ActiveJobs)
+	}
+
+	requeued := js.messages(SubjectScansNew)
+	if len(requeued) != 1 {
+		t.Fatalf("expected 1 message on %s, got %d", SubjectScansNew, len(requeued))
+	}
+	var job ScanJob
+	if err := json.Unmarshal(requeued[0], &job); err != nil {
+		t.Fatalf("failed to unmarshal requeued job: %v", err)
+	}
+	if job.Attempts != 1 {
+		t.Fatalf("expected attempts=1, got %d", job.Attempts)
+	}
+	if job.ProbeID != "" {
+		t.Fatalf("expected requeued job to have no probe assigned, got %q", job.ProbeID)
+	}
+
+	if len(js.messages(SubjectProbesEvicted)) != 1 {
+		t.Fatalf("expected a probes.evicted event to be published")
+	}
+}
+
+func TestEvictStalledProbes_RecentHeartbeatIsNotEvicted(t *testing.T) {
+	base := time.Now()
+	js := newFakeJetStream()
+	o := newTestOrchestrator(js, func() time.Time { return base.Add(10 * time.Second) })
+
+	o.probes["probe-1"] = &ProbeInfo{
+		ProbeID:       "probe-1",
+		Status:        "online",
+		LastHeartbeat: base, // heartbeat recém recebido, 10s < probeTimeout (45s)
+	}
+
+	o.evictStalledProbes()
+
+	if o.probes["probe-1"].Status != "online" {
+		t.Fatalf("expected probe to remain online, got status %q", o.probes["probe-1"].Status)
+	}
+	if len(js.messages(SubjectProbesEvicted)) != 0 {
+		t.Fatalf("did not expect a probes.evicted event")
+	}
+}
+
+// TestEvictStalledProbes_ConcurrentHeartbeatDoesNotRace exercita o watchdog
+// competindo pelo mutex do Orchestrator com heartbeats concorrentes, do jeito
+// que handleProbeStatus atualiza LastHeartbeat/Status em produção. O teste não
+// afirma qual dos dois "vence" a corrida (depende de timing), só que nenhuma
+// das duas goroutines observa/deixa o estado do probe corrompido. Rode com
+// -race para pegar qualquer acesso fora do mutex.
+func TestEvictStalledProbes_ConcurrentHeartbeatDoesNotRace(t *testing.T) {
+	base := time.Now()
+	js := newFakeJetStream()
+	o := newTestOrchestrator(js, func() time.Time { return base.Add(50 * time.Second) })
+
+	o.probes["probe-1"] = &ProbeInfo{
+		ProbeID:       "probe-1",
+		Status:        "online",
+		LastHeartbeat: base,
+		ActiveJobs:    []string{"job-1"},
+		InFlight:      1,
+	}
+	o.jobs["job-1"] = &ScanJob{JobID: "job-1", Status: "assigned", ProbeID: "probe-1"}
+
+	const iterations = 200
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	// Heartbeats atrasados chegando continuamente, competindo com o watchdog
+	// pelo mesmo probe e pelo mesmo mutex.
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			o.mu.Lock()
+			if probe, ok := o.probes["probe-1"]; ok {
+				probe.LastHeartbeat = time.Now()
+				probe.Status = "online"
+			}
+			o.mu.Unlock()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			o.evictStalledProbes()
+		}
+	}()
+
+	wg.Wait()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	status := o.probes["probe-1"].Status
+	if status != "online" && status != "offline" {
+		t.Fatalf("probe left in inconsistent status %q", status)
+	}
+}
+
+func TestRequeueJob_ExceedingMaxAttemptsGoesToDeadLetter(t *testing.T) {
+	js := newFakeJetStream()
+	o := newTestOrchestrator(js, time.Now)
+	o.maxAttempts = 2
+
+	o.jobs["job-1"] = &ScanJob{JobID: "job-1", Status: "assigned", Attempts: 2}
+
+	o.requeueJob("job-1")
+
+	if len(js.messages(SubjectScansNew)) != 0 {
+		t.Fatalf("expected no message on %s once max attempts is exceeded", SubjectScansNew)
+	}
+	dead := js.messages(SubjectScansDead)
+	if len(dead) != 1 {
+		t.Fatalf("expected 1 message on %s, got %d", SubjectScansDead, len(dead))
+	}
+	var job ScanJob
+	if err := json.Unmarshal(dead[0], &job); err != nil {
+		t.Fatalf("failed to unmarshal dead job: %v", err)
+	}
+	if job.Status != "dead" {
+		t.Fatalf("expected job status to be dead, got %q", job.Status)
+	}
+}
@@ -0,0 +1,168 @@
+// Package authn implementa a autenticação de probes usada pelos três
+// binários (API, orchestrator e webhook): tokens JWT assinados com uma
+// chave Ed25519 do operador, carregando claims de identidade e escopo.
+package authn
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrMissingAuthorization indica que a requisição não trouxe um header Authorization.
+var ErrMissingAuthorization = errors.New("authn: missing authorization header")
+
+// Claims carrega a identidade e o escopo de autorização de um probe.
+type Claims struct {
+	jwt.RegisteredClaims
+	ProbeID      string   `json:"probe_id"`
+	Location     string   `json:"location"`
+	AllowedCIDRs []string `json:"allowed_cidrs"`
+}
+
+// AllowsTarget reporta se o probe está autorizado a escanear o alvo informado.
+// target pode ser um IP isolado ou um CIDR; é considerado permitido quando
+// está contido em pelo menos um dos AllowedCIDRs da claim.
+func (c *Claims) AllowsTarget(target string) bool {
+	targetIP, targetNet, err := net.ParseCIDR(target)
+	if err != nil {
+		targetIP = net.ParseIP(target)
+		if targetIP == nil {
+			return false
+		}
+	}
+
+	for _, cidr := range c.AllowedCIDRs {
+		_, allowedNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		if targetNet != nil {
+			if allowedNet.Contains(targetNet.IP) && allowedNet.Contains(lastIP(targetNet)) {
+				return true
+			}
+			continue
+		}
+		if allowedNet.Contains(targetIP) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastIP(n *net.IPNet) net.IP {
+	ip := make(net.IP, len(n.IP))
+	copy(ip, n.IP)
+	for i, b := range n.Mask {
+		ip[i] |= ^b
+	}
+	return ip
+}
+
+// LoadPublicKey lê uma chave pública Ed25519 em PEM do caminho informado,
+// tipicamente apontado pela env var NATS_JWT_PUBKEY.
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authn: failed to read public key: %w", err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("authn: invalid PEM public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("authn: failed to parse public key: %w", err)
+	}
+
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("authn: key is not Ed25519")
+	}
+	return edPub, nil
+}
+
+// LoadPrivateKey lê uma chave privada Ed25519 em PEM do caminho informado,
+// usada pela CLI de emissão de tokens do operador.
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("authn: failed to read private key: %w", err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("authn: invalid PEM private key")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("authn: failed to parse private key: %w", err)
+	}
+
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("authn: key is not Ed25519")
+	}
+	return edKey, nil
+}
+
+// ExtractBearerToken extrai o token de um header "Authorization: Bearer <token>".
+func ExtractBearerToken(header string) (string, error) {
+	if header == "" {
+		return "", ErrMissingAuthorization
+	}
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", fmt.Errorf("authn: authorization header is not a bearer token")
+	}
+	return strings.TrimPrefix(header, "Bearer "), nil
+}
+
+// ParseToken valida um JWT EdDSA assinado pelo operador e retorna suas claims.
+func ParseToken(tokenString string, pub ed25519.PublicKey) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, fmt.Errorf("authn: unexpected signing method %v", t.Header["alg"])
+		}
+		return pub, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("authn: invalid token: %w", err)
+	}
+	if !token.Valid {
+		return nil, errors.New("authn: token is not valid")
+	}
+	if claims.ProbeID == "" {
+		return nil, errors.New("authn: token missing probe_id claim")
+	}
+	return claims, nil
+}
+
+// MintToken assina um token de probe com a chave privada do operador.
+func MintToken(priv ed25519.PrivateKey, probeID, location string, allowedCIDRs []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			Subject:   probeID,
+		},
+		ProbeID:      probeID,
+		Location:     location,
+		AllowedCIDRs: allowedCIDRs,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	return token.SignedString(priv)
+}
@@ -0,0 +1,110 @@
+package authn
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+)
+
+func TestClaims_AllowsTarget(t *testing.T) {
+	claims := &Claims{AllowedCIDRs: []string{"10.0.0.0/24", "192.168.1.5/32"}}
+
+	cases := []struct {
+		name   string
+		target string
+		want   bool
+	}{
+		{"ip inside cidr", "10.0.0.42", true},
+		{"ip outside any cidr", "10.0.1.1", false},
+		{"exact /32 match", "192.168.1.5", true},
+		{"exact /32 mismatch", "192.168.1.6", false},
+		{"cidr fully contained", "10.0.0.0/25", true},
+		{"cidr only partially contained", "10.0.0.0/23", false}, // spills into 10.0.1.0/24, outside AllowedCIDRs
+		{"cidr wider than any allowed range", "10.0.0.0/16", false},
+		{"hostname is never allowed", "scanme.example.com", false},
+		{"garbage input", "not-an-ip", false},
+	}
+
+	for _, c := range cases {
+		if got := claims.AllowsTarget(c.target); got != c.want {
+			t.Errorf("%s: AllowsTarget(%q) = %v, want %v", c.name, c.target, got, c.want)
+		}
+	}
+}
+
+func TestClaims_AllowsTarget_NoAllowedCIDRs(t *testing.T) {
+	claims := &Claims{}
+	if claims.AllowsTarget("10.0.0.1") {
+		t.Fatal("expected no target to be allowed when AllowedCIDRs is empty")
+	}
+}
+
+func TestMintTokenAndParseToken_RoundTrip(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	signed, err := MintToken(priv, "probe-1", "eu-west", []string{"10.0.0.0/24"}, time.Hour)
+	if err != nil {
+		t.Fatalf("MintToken: %v", err)
+	}
+
+	claims, err := ParseToken(signed, pub)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.ProbeID != "probe-1" || claims.Location != "eu-west" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+}
+
+func TestParseToken_RejectsWrongKey(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	signed, err := MintToken(priv, "probe-1", "eu-west", nil, time.Hour)
+	if err != nil {
+		t.Fatalf("MintToken: %v", err)
+	}
+
+	if _, err := ParseToken(signed, otherPub); err == nil {
+		t.Fatal("expected ParseToken to reject a token signed by a different key")
+	}
+}
+
+func TestParseToken_RejectsExpiredToken(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	signed, err := MintToken(priv, "probe-1", "eu-west", nil, -time.Minute)
+	if err != nil {
+		t.Fatalf("MintToken: %v", err)
+	}
+
+	if _, err := ParseToken(signed, pub); err == nil {
+		t.Fatal("expected ParseToken to reject an expired token")
+	}
+}
+
+func TestExtractBearerToken(t *testing.T) {
+	if _, err := ExtractBearerToken(""); err != ErrMissingAuthorization {
+		t.Fatalf("expected ErrMissingAuthorization for empty header, got %v", err)
+	}
+	if _, err := ExtractBearerToken("Basic abc123"); err == nil {
+		t.Fatal("expected an error for a non-Bearer scheme")
+	}
+	token, err := ExtractBearerToken("Bearer abc123")
+	if err != nil || token != "abc123" {
+		t.Fatalf("expected (\"abc123\", nil), got (%q, %v)", token, err)
+	}
+}
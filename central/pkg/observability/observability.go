@@ -0,0 +1,98 @@
+// Package observability fornece as métricas Prometheus e os endpoints de
+// readiness compartilhados pelos três binários, inspirado no padrão de
+// status server do pilot-agent do Istio: /metrics sempre disponível,
+// /ready reportando 503 até as dependências do processo estarem prontas.
+package observability
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics agrega os contadores, histogramas e gauges expostos em /metrics.
+type Metrics struct {
+	ScansCreatedTotal     *prometheus.CounterVec
+	ScansCompletedTotal   *prometheus.CounterVec
+	ResultsReceivedTotal  prometheus.Counter
+	ScanDurationSeconds   prometheus.Histogram
+	ForwardLatencySeconds prometheus.Histogram
+	ProbesOnline          prometheus.Gauge
+	ProbesBusy            prometheus.Gauge
+	JobsPending           prometheus.Gauge
+}
+
+// NewMetrics cria e registra as métricas no registry padrão do cliente Prometheus.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		ScansCreatedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "scans_created_total",
+			Help: "Total de scans criados, por tipo.",
+		}, []string{"type"}),
+		ScansCompletedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "scans_completed_total",
+			Help: "Total de scans finalizados, por status.",
+		}, []string{"status"}),
+		ResultsReceivedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "results_received_total",
+			Help: "Total de resultados de scan recebidos pelo webhook.",
+		}),
+		ScanDurationSeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "scan_duration_seconds",
+			Help:    "Duração dos scans, de CreatedAt até CompletedAt.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 12), // 1s .. ~34min
+		}),
+		ForwardLatencySeconds: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "webhook_forward_latency_seconds",
+			Help:    "Latência do encaminhamento de resultados para o sistema externo configurado.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		ProbesOnline: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probes_online",
+			Help: "Número de probes atualmente online.",
+		}),
+		ProbesBusy: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "probes_busy",
+			Help: "Número de probes com pelo menos um job em execução.",
+		}),
+		JobsPending: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "jobs_pending",
+			Help: "Número de jobs aguardando atribuição a um probe.",
+		}),
+	}
+
+	prometheus.MustRegister(
+		m.ScansCreatedTotal,
+		m.ScansCompletedTotal,
+		m.ResultsReceivedTotal,
+		m.ScanDurationSeconds,
+		m.ForwardLatencySeconds,
+		m.ProbesOnline,
+		m.ProbesBusy,
+		m.JobsPending,
+	)
+
+	return m
+}
+
+// Mount registra /metrics no router informado.
+func Mount(r chi.Router) {
+	r.Handle("/metrics", promhttp.Handler())
+}
+
+// MountReady registra /ready no router informado. check deve retornar erro
+// enquanto o serviço não estiver pronto para receber tráfego; um /ready verde
+// é distinto do /health sempre-200 já existente.
+func MountReady(r chi.Router, check func() error) {
+	r.Get("/ready", func(w http.ResponseWriter, req *http.Request) {
+		if err := check(); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ready"))
+	})
+}
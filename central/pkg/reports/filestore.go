@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileStore persiste relatórios como arquivos XML em REPORTS_DIR, com o
+// índice de metadados num BoltDB ao lado. É o backend default: não exige
+// nenhuma dependência externa além de um disco local.
+type FileStore struct {
+	dir   string
+	index *boltIndex
+}
+
+// NewFileStore cria (se necessário) baseDir e o índice de metadados, e
+// devolve um Store que grava relatórios em baseDir/reports/<job_id>.xml.
+func NewFileStore(baseDir string) (*FileStore, error) {
+	reportsDir := filepath.Join(baseDir, "reports")
+	if err := os.MkdirAll(reportsDir, 0755); err != nil {
+		return nil, fmt.Errorf("reports: failed to create reports dir: %w", err)
+	}
+
+	index, err := openIndex(filepath.Join(baseDir, "index.db"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileStore{dir: reportsDir, index: index}, nil
+}
+
+func (s *FileStore) path(jobID string) string {
+	return filepath.Join(s.dir, jobID+".xml")
+}
+
+func (s *FileStore) Put(_ context.Context, meta Metadata, reportXML []byte) error {
+	if err := ValidateJobID(meta.JobID); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path(meta.JobID), reportXML, 0644); err != nil {
+		return fmt.Errorf("reports: failed to write report for %s: %w", meta.JobID, err)
+	}
+	return s.index.put(meta)
+}
+
+func (s *FileStore) Get(_ context.Context, jobID string) (Metadata, []byte, error) {
+	if err := ValidateJobID(jobID); err != nil {
+		return Metadata{}, nil, err
+	}
+
+	meta, found, err := s.index.get(jobID)
+	if err != nil {
+		return Metadata{}, nil, err
+	}
+	if !found {
+		return Metadata{}, nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(s.path(jobID))
+	if err != nil {
+		return Metadata{}, nil, fmt.Errorf("reports: failed to read report for %s: %w", jobID, err)
+	}
+	return meta, data, nil
+}
+
+func (s *FileStore) List(_ context.Context, filter ListFilter) ([]Metadata, error) {
+	return s.index.list(filter)
+}
+
+func (s *FileStore) Close() error {
+	return s.index.close()
+}
@@ -0,0 +1,70 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFileStore_PutGetRoundTrip(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	meta := Metadata{
+		JobID:       "550e8400-e29b-41d4-a716-446655440000",
+		ProbeID:     "probe-1",
+		Status:      "completed",
+		CompletedAt: time.Now(),
+		VulnsHigh:   2,
+	}
+	xml := []byte("<report/>")
+
+	if err := store.Put(context.Background(), meta, xml); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, gotXML, err := store.Get(context.Background(), meta.JobID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.ProbeID != meta.ProbeID || got.VulnsHigh != meta.VulnsHigh {
+		t.Fatalf("Get returned unexpected metadata: %+v", got)
+	}
+	if string(gotXML) != string(xml) {
+		t.Fatalf("Get returned unexpected XML: %q", gotXML)
+	}
+}
+
+func TestFileStore_GetMissingJobReturnsErrNotFound(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	_, _, err = store.Get(context.Background(), "550e8400-e29b-41d4-a716-446655440000")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFileStore_RejectsPathTraversalJobID(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	defer store.Close()
+
+	meta := Metadata{JobID: "../../../../tmp/evil", Status: "completed"}
+	if err := store.Put(context.Background(), meta, []byte("<report/>")); !errors.Is(err, ErrInvalidJobID) {
+		t.Fatalf("expected ErrInvalidJobID on Put, got %v", err)
+	}
+
+	if _, _, err := store.Get(context.Background(), "../../../../tmp/evil"); !errors.Is(err, ErrInvalidJobID) {
+		t.Fatalf("expected ErrInvalidJobID on Get, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package reports
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var metadataBucket = []byte("reports_metadata")
+
+// boltIndex é o "pequeno índice" de metadados compartilhado pelos backends de
+// Store: o blob (XML) fica no filesystem ou em S3, mas saber quais job_ids
+// existem e filtrar por data/status não deveria exigir ler cada blob.
+type boltIndex struct {
+	db *bolt.DB
+}
+
+func openIndex(path string) (*boltIndex, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("reports: failed to open index at %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(metadataBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("reports: failed to initialize index: %w", err)
+	}
+
+	return &boltIndex{db: db}, nil
+}
+
+func (i *boltIndex) put(meta Metadata) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("reports: failed to marshal metadata: %w", err)
+	}
+
+	return i.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(metadataBucket).Put([]byte(meta.JobID), data)
+	})
+}
+
+func (i *boltIndex) get(jobID string) (Metadata, bool, error) {
+	var meta Metadata
+	var found bool
+
+	err := i.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(metadataBucket).Get([]byte(jobID))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &meta)
+	})
+	if err != nil {
+		return Metadata{}, false, fmt.Errorf("reports: failed to read metadata for %s: %w", jobID, err)
+	}
+	return meta, found, nil
+}
+
+func (i *boltIndex) list(filter ListFilter) ([]Metadata, error) {
+	var results []Metadata
+
+	err := i.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(metadataBucket).ForEach(func(_, data []byte) error {
+			var meta Metadata
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return err
+			}
+			if !filter.Since.IsZero() && meta.CompletedAt.Before(filter.Since) {
+				return nil
+			}
+			if filter.Status != "" && meta.Status != filter.Status {
+				return nil
+			}
+			results = append(results, meta)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reports: failed to list metadata: %w", err)
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		return results[a].CompletedAt.After(results[b].CompletedAt)
+	})
+	return results, nil
+}
+
+func (i *boltIndex) close() error {
+	return i.db.Close()
+}
@@ -0,0 +1,68 @@
+// Package reports persiste o XML dos relatórios de scan recebidos pelo
+// webhook e o índice de metadados usado para listagem e estatísticas,
+// atrás de uma interface Store que permite trocar o backend de blobs
+// (sistema de arquivos local, S3/MinIO) sem alterar os chamadores.
+package reports
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+)
+
+// ErrNotFound indica que nenhum relatório existe para o job_id informado.
+var ErrNotFound = errors.New("reports: not found")
+
+// ErrInvalidJobID indica que o job_id tem caracteres fora do alfabeto de um
+// UUID (hex e hífen), e por isso não é seguro usá-lo para compor uma
+// chave/caminho de blob.
+var ErrInvalidJobID = errors.New("reports: invalid job_id")
+
+var jobIDPattern = regexp.MustCompile(`^[0-9a-fA-F-]{1,64}$`)
+
+// ValidateJobID rejeita qualquer job_id vazio, com mais de 64 caracteres ou
+// com caracteres fora de hex e hífen (o alfabeto dos UUIDs gerados pela API;
+// o layout 8-4-4-4-12 não é verificado), antes que o valor chegue a compor
+// uma chave S3 ou um caminho de arquivo — job_id vem de entrada externa (corpo do POST
+// /results, relayed verbatim via NATS) e nunca deve ser usado cru num path.
+func ValidateJobID(jobID string) error {
+	if !jobIDPattern.MatchString(jobID) {
+		return ErrInvalidJobID
+	}
+	return nil
+}
+
+// Metadata descreve um relatório de scan persistido, sem o XML em si.
+type Metadata struct {
+	JobID        string    `json:"job_id"`
+	ProbeID      string    `json:"probe_id"`
+	Target       string    `json:"target,omitempty"`
+	Status       string    `json:"status"`
+	CompletedAt  time.Time `json:"completed_at"`
+	HostsScanned int       `json:"hosts_scanned"`
+	VulnsHigh    int       `json:"vulns_high"`
+	VulnsMedium  int       `json:"vulns_medium"`
+	VulnsLow     int       `json:"vulns_low"`
+}
+
+// ListFilter restringe List a um intervalo de tempo e, opcionalmente, a um status.
+type ListFilter struct {
+	Since  time.Time // zero value não filtra por data
+	Status string    // vazio não filtra por status
+}
+
+// Store é o backend de persistência de relatórios: o XML bruto vai para o
+// armazenamento de blobs (arquivo local ou objeto S3) e os Metadata para o
+// índice local, usados por List/Stats sem precisar ler os blobs.
+type Store interface {
+	// Put grava o XML do relatório e seus metadados, indexados por job_id.
+	Put(ctx context.Context, meta Metadata, reportXML []byte) error
+	// Get devolve os metadados e o XML de um relatório. Retorna ErrNotFound
+	// quando job_id não existe.
+	Get(ctx context.Context, jobID string) (Metadata, []byte, error)
+	// List devolve os metadados que atendem ao filtro, mais recentes primeiro.
+	List(ctx context.Context, filter ListFilter) ([]Metadata, error)
+	// Close libera os recursos do índice (ex. o arquivo do BoltDB).
+	Close() error
+}
@@ -0,0 +1,28 @@
+package reports
+
+import "testing"
+
+func TestValidateJobID(t *testing.T) {
+	cases := []struct {
+		jobID   string
+		wantErr bool
+	}{
+		{"550e8400-e29b-41d4-a716-446655440000", false},
+		{"job-1", true}, // não é formato UUID, só dígitos/hex e hífen
+		{"", true},
+		{"../../../etc/passwd", true},
+		{"../../tmp/x", true},
+		{"foo/bar", true},
+		{`foo\bar`, true},
+	}
+
+	for _, c := range cases {
+		err := ValidateJobID(c.jobID)
+		if c.wantErr && err == nil {
+			t.Errorf("ValidateJobID(%q): expected error, got nil", c.jobID)
+		}
+		if !c.wantErr && err != nil {
+			t.Errorf("ValidateJobID(%q): unexpected error: %v", c.jobID, err)
+		}
+	}
+}
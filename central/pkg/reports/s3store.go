@@ -0,0 +1,113 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// S3Store persiste o XML dos relatórios em um bucket S3 ou compatível
+// (MinIO), mantendo o mesmo índice local de metadados do FileStore, já que
+// listagem e estatísticas são consultadas com frequência bem maior do que o
+// XML em si é baixado.
+type S3Store struct {
+	client *s3.Client
+	bucket string
+	index  *boltIndex
+}
+
+// NewS3Store cria um client S3 apontando para endpoint (deixe vazio para usar
+// a resolução padrão da AWS; aponte para um MinIO para object storage
+// on-premises), e abre o índice de metadados em indexPath.
+func NewS3Store(ctx context.Context, bucket, endpoint, indexPath string) (*S3Store, error) {
+	if bucket == "" {
+		return nil, errors.New("reports: s3 bucket is required")
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reports: failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true // necessário para a maioria das implantações de MinIO
+		}
+	})
+
+	index, err := openIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3Store{client: client, bucket: bucket, index: index}, nil
+}
+
+func (s *S3Store) key(jobID string) string {
+	return jobID + ".xml"
+}
+
+func (s *S3Store) Put(ctx context.Context, meta Metadata, reportXML []byte) error {
+	if err := ValidateJobID(meta.JobID); err != nil {
+		return err
+	}
+
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(meta.JobID)),
+		Body:   bytes.NewReader(reportXML),
+	})
+	if err != nil {
+		return fmt.Errorf("reports: failed to upload report for %s: %w", meta.JobID, err)
+	}
+	return s.index.put(meta)
+}
+
+func (s *S3Store) Get(ctx context.Context, jobID string) (Metadata, []byte, error) {
+	if err := ValidateJobID(jobID); err != nil {
+		return Metadata{}, nil, err
+	}
+
+	meta, found, err := s.index.get(jobID)
+	if err != nil {
+		return Metadata{}, nil, err
+	}
+	if !found {
+		return Metadata{}, nil, ErrNotFound
+	}
+
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(jobID)),
+	})
+	if err != nil {
+		var notFound *smithyhttp.ResponseError
+		if errors.As(err, &notFound) && notFound.HTTPStatusCode() == 404 {
+			return Metadata{}, nil, ErrNotFound
+		}
+		return Metadata{}, nil, fmt.Errorf("reports: failed to download report for %s: %w", jobID, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return Metadata{}, nil, fmt.Errorf("reports: failed to read report body for %s: %w", jobID, err)
+	}
+	return meta, data, nil
+}
+
+func (s *S3Store) List(_ context.Context, filter ListFilter) ([]Metadata, error) {
+	return s.index.list(filter)
+}
+
+func (s *S3Store) Close() error {
+	return s.index.close()
+}
@@ -0,0 +1,69 @@
+package reports
+
+import "sort"
+
+// TargetVulnCount agrega o total de vulnerabilidades encontradas em um alvo.
+type TargetVulnCount struct {
+	Target string `json:"target"`
+	Total  int    `json:"total_vulns"`
+}
+
+// ProbeThroughput agrega quantos scans um probe concluiu na janela considerada.
+type ProbeThroughput struct {
+	ProbeID string `json:"probe_id"`
+	Scans   int    `json:"scans"`
+}
+
+// Stats resume os relatórios de uma janela de tempo, no mesmo espírito de um
+// endpoint de estatísticas de protocolo, mas restrito à telemetria de scans.
+type Stats struct {
+	TotalScans      int               `json:"total_scans"`
+	VulnsHigh       int               `json:"vulns_high"`
+	VulnsMedium     int               `json:"vulns_medium"`
+	VulnsLow        int               `json:"vulns_low"`
+	TopTargets      []TargetVulnCount `json:"top_targets"`
+	ProbeThroughput []ProbeThroughput `json:"probe_throughput"`
+}
+
+// Summarize agrega uma lista de Metadata (tipicamente o resultado de
+// Store.List para uma janela de 24h/7d) em um Stats, com os topN alvos de
+// maior contagem de vulnerabilidades.
+func Summarize(items []Metadata, topN int) Stats {
+	var stats Stats
+
+	vulnsByTarget := make(map[string]int)
+	scansByProbe := make(map[string]int)
+
+	for _, meta := range items {
+		stats.TotalScans++
+		stats.VulnsHigh += meta.VulnsHigh
+		stats.VulnsMedium += meta.VulnsMedium
+		stats.VulnsLow += meta.VulnsLow
+
+		if meta.Target != "" {
+			vulnsByTarget[meta.Target] += meta.VulnsHigh + meta.VulnsMedium + meta.VulnsLow
+		}
+		if meta.ProbeID != "" {
+			scansByProbe[meta.ProbeID]++
+		}
+	}
+
+	for target, total := range vulnsByTarget {
+		stats.TopTargets = append(stats.TopTargets, TargetVulnCount{Target: target, Total: total})
+	}
+	sort.Slice(stats.TopTargets, func(i, j int) bool {
+		return stats.TopTargets[i].Total > stats.TopTargets[j].Total
+	})
+	if len(stats.TopTargets) > topN {
+		stats.TopTargets = stats.TopTargets[:topN]
+	}
+
+	for probeID, scans := range scansByProbe {
+		stats.ProbeThroughput = append(stats.ProbeThroughput, ProbeThroughput{ProbeID: probeID, Scans: scans})
+	}
+	sort.Slice(stats.ProbeThroughput, func(i, j int) bool {
+		return stats.ProbeThroughput[i].Scans > stats.ProbeThroughput[j].Scans
+	})
+
+	return stats
+}
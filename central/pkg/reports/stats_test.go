@@ -0,0 +1,33 @@
+package reports
+
+import "testing"
+
+func TestSummarize(t *testing.T) {
+	items := []Metadata{
+		{Target: "10.0.0.1", ProbeID: "probe-1", VulnsHigh: 3, VulnsMedium: 1},
+		{Target: "10.0.0.1", ProbeID: "probe-1", VulnsHigh: 1},
+		{Target: "10.0.0.2", ProbeID: "probe-2", VulnsLow: 2},
+	}
+
+	stats := Summarize(items, 1)
+
+	if stats.TotalScans != 3 {
+		t.Fatalf("expected TotalScans=3, got %d", stats.TotalScans)
+	}
+	if stats.VulnsHigh != 4 || stats.VulnsMedium != 1 || stats.VulnsLow != 2 {
+		t.Fatalf("unexpected vuln totals: %+v", stats)
+	}
+	if len(stats.TopTargets) != 1 || stats.TopTargets[0].Target != "10.0.0.1" || stats.TopTargets[0].Total != 5 {
+		t.Fatalf("expected top target 10.0.0.1 with 5 vulns, got %+v", stats.TopTargets)
+	}
+	if len(stats.ProbeThroughput) != 2 {
+		t.Fatalf("expected throughput for 2 probes, got %+v", stats.ProbeThroughput)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	stats := Summarize(nil, 10)
+	if stats.TotalScans != 0 || len(stats.TopTargets) != 0 || len(stats.ProbeThroughput) != 0 {
+		t.Fatalf("expected zero-value Stats for empty input, got %+v", stats)
+	}
+}
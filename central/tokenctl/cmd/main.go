@@ -0,0 +1,53 @@
+// tokenctl é uma CLI do operador para emitir tokens de probe assinados com
+// a chave privada Ed25519 do operador, usada para provisionar novos probes
+// sem precisar redeployar o orchestrator.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/authn"
+)
+
+func main() {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	var (
+		keyPath  = flag.String("key", os.Getenv("NATS_JWT_PRIVKEY"), "path to the operator's Ed25519 private key (PEM)")
+		probeID  = flag.String("probe-id", "", "unique identifier for the probe")
+		location = flag.String("location", "", "probe location code, e.g. eu-west")
+		cidrs    = flag.String("cidrs", "", "comma-separated list of CIDRs the probe may scan")
+		ttl      = flag.Duration("ttl", 8760*time.Hour, "token validity duration")
+	)
+	flag.Parse()
+
+	if *keyPath == "" || *probeID == "" || *location == "" || *cidrs == "" {
+		fmt.Fprintln(os.Stderr, "usage: tokenctl -key <privkey.pem> -probe-id <id> -location <loc> -cidrs <cidr1,cidr2>")
+		os.Exit(1)
+	}
+
+	priv, err := authn.LoadPrivateKey(*keyPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load operator private key")
+	}
+
+	allowedCIDRs := strings.Split(*cidrs, ",")
+	for i, c := range allowedCIDRs {
+		allowedCIDRs[i] = strings.TrimSpace(c)
+	}
+
+	token, err := authn.MintToken(priv, *probeID, *location, allowedCIDRs, *ttl)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to mint token")
+	}
+
+	fmt.Println(token)
+}
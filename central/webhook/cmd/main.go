@@ -1,30 +1,49 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/authn"
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/observability"
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/reports"
 )
 
 const (
 	SubjectScansCompleted = "scans.completed"
 	SubjectScansResults   = "scans.results"
+	SubjectScansDead      = "scans.dead" // jobs que excederam MaxAttempts no orchestrator
+
+	SubjectReportsGet   = "reports.get"   // Request/reply: metadados + XML de um relatório
+	SubjectReportsList  = "reports.list"  // Request/reply: listagem paginada de relatórios
+	SubjectReportsStats = "reports.stats" // Request/reply: agregados de telemetria de scans
+
+	maxDeadJobsKept = 100 // janela exibida em /results/dead
 )
 
 // ScanResult representa resultado de um scan
 type ScanResult struct {
 	JobID       string    `json:"job_id"`
 	ProbeID     string    `json:"probe_id"`
+	Target      string    `json:"target,omitempty"`
 	Status      string    `json:"status"`
 	CompletedAt time.Time `json:"completed_at"`
 	ReportXML   string    `json:"report_xml,omitempty"` // base64 encoded
@@ -37,8 +56,37 @@ type ScanResult struct {
 }
 
 type WebhookServer struct {
-	nc          *nats.Conn
-	forwardURL  string // URL externa para encaminhar resultados
+	nc         *nats.Conn
+	forwardURL string // URL externa para encaminhar resultados
+	jwtPubKey  ed25519.PublicKey
+	metrics    *observability.Metrics
+	store      reports.Store
+	outbox     *outbox
+
+	deadMu   sync.Mutex
+	deadJobs []json.RawMessage // últimos jobs recebidos em scans.dead, para /results/dead
+}
+
+// handleScanDead acumula jobs que o orchestrator desistiu de reentregar, para
+// que o operador possa inspecioná-los via /results/dead.
+func (s *WebhookServer) handleScanDead(msg *nats.Msg) {
+	s.deadMu.Lock()
+	defer s.deadMu.Unlock()
+
+	s.deadJobs = append(s.deadJobs, json.RawMessage(append([]byte(nil), msg.Data...)))
+	if len(s.deadJobs) > maxDeadJobsKept {
+		s.deadJobs = s.deadJobs[len(s.deadJobs)-maxDeadJobsKept:]
+	}
+}
+
+// handleListDeadJobs lista os jobs mais recentes que caíram na DLQ scans.dead.
+func (s *WebhookServer) handleListDeadJobs(w http.ResponseWriter, r *http.Request) {
+	s.deadMu.Lock()
+	jobs := append([]json.RawMessage(nil), s.deadJobs...)
+	s.deadMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jobs": jobs})
 }
 
 func main() {
@@ -81,9 +129,55 @@ func main() {
 
 	log.Info().Str("url", natsURL).Msg("Connected to NATS")
 
+	jwtPubKeyPath := os.Getenv("NATS_JWT_PUBKEY")
+	if jwtPubKeyPath == "" {
+		log.Fatal().Msg("NATS_JWT_PUBKEY is required to authenticate probes")
+	}
+	jwtPubKey, err := authn.LoadPublicKey(jwtPubKeyPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load probe JWT public key")
+	}
+
+	store, err := newReportsStore()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open reports store")
+	}
+	defer store.Close()
+
+	outboxDir := os.Getenv("OUTBOX_DIR")
+	if outboxDir == "" {
+		outboxDir = "./data/outbox"
+	}
+	ob, err := newOutbox(outboxDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open forward outbox")
+	}
+
 	server := &WebhookServer{
 		nc:         nc,
 		forwardURL: os.Getenv("FORWARD_URL"), // opcional
+		jwtPubKey:  jwtPubKey,
+		metrics:    observability.NewMetrics(),
+		store:      store,
+		outbox:     ob,
+	}
+
+	if _, err := nc.Subscribe(SubjectScansDead, server.handleScanDead); err != nil {
+		log.Fatal().Err(err).Msg("Failed to subscribe to scans.dead")
+	}
+
+	if _, err := nc.Subscribe(SubjectReportsGet, server.handleReportsGet); err != nil {
+		log.Fatal().Err(err).Msg("Failed to subscribe to reports.get")
+	}
+	if _, err := nc.Subscribe(SubjectReportsList, server.handleReportsList); err != nil {
+		log.Fatal().Err(err).Msg("Failed to subscribe to reports.list")
+	}
+	if _, err := nc.Subscribe(SubjectReportsStats, server.handleReportsStats); err != nil {
+		log.Fatal().Err(err).Msg("Failed to subscribe to reports.stats")
+	}
+
+	if server.forwardURL != "" {
+		go server.runOutboxDrainLoop()
 	}
 
 	// Setup router
@@ -94,10 +188,18 @@ func main() {
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{"status":"healthy"}`))
 	})
+	observability.Mount(r)
+	observability.MountReady(r, func() error {
+		if nc.Status() != nats.CONNECTED {
+			return fmt.Errorf("NATS connection is %s, not CONNECTED", nc.Status())
+		}
+		return nil
+	})
 
 	// Endpoint para receber resultados dos probes
 	r.Post("/results", server.handleResults)
 	r.Post("/api/v1/results", server.handleResults)
+	r.Get("/results/dead", server.handleListDeadJobs)
 
 	// Start server
 	port := os.Getenv("WEBHOOK_PORT")
@@ -124,10 +226,18 @@ func main() {
 // handleResults processa resultados dos probes
 func (s *WebhookServer) handleResults(w http.ResponseWriter, r *http.Request) {
 	// Validate probe token
-	token := r.Header.Get("Authorization")
-	if token == "" {
-		// Para MVP, aceita sem token mas loga warning
-		log.Warn().Msg("Received result without authorization")
+	token, err := authn.ExtractBearerToken(r.Header.Get("Authorization"))
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejected result: missing or malformed authorization header")
+		http.Error(w, `{"error":"missing or malformed authorization header"}`, http.StatusUnauthorized)
+		return
+	}
+
+	claims, err := authn.ParseToken(token, s.jwtPubKey)
+	if err != nil {
+		log.Warn().Err(err).Msg("Rejected result: invalid probe token")
+		http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+		return
 	}
 
 	// Read body
@@ -146,6 +256,15 @@ func (s *WebhookServer) handleResults(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if result.ProbeID != claims.ProbeID {
+		log.Warn().
+			Str("claimed_probe_id", claims.ProbeID).
+			Str("result_probe_id", result.ProbeID).
+			Msg("Rejected result: probe_id does not match token")
+		http.Error(w, `{"error":"probe_id does not match token"}`, http.StatusForbidden)
+		return
+	}
+
 	log.Info().
 		Str("job_id", result.JobID).
 		Str("probe_id", result.ProbeID).
@@ -154,6 +273,9 @@ func (s *WebhookServer) handleResults(w http.ResponseWriter, r *http.Request) {
 		Int("vulns_medium", result.Summary.VulnsMedium).
 		Msg("Received scan result")
 
+	s.metrics.ResultsReceivedTotal.Inc()
+	s.persistReport(result)
+
 	// Publish to NATS para orquestrador
 	s.nc.Publish(SubjectScansCompleted, body)
 
@@ -170,22 +292,80 @@ func (s *WebhookServer) handleResults(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"received"}`))
 }
 
-// forwardResult encaminha resultado para sistema externo
+// persistReport grava os metadados do resultado (e o XML do relatório,
+// quando presente) no reports.Store, para consulta posterior via
+// reports.get/list/stats. Scans sem ReportXML (ex. status "failed"), ou com
+// um ReportXML que não decodifica, ainda geram metadados, gravados junto com
+// um blob de relatório vazio.
+func (s *WebhookServer) persistReport(result ScanResult) {
+	var xmlBytes []byte
+	if result.ReportXML != "" {
+		decoded, err := base64.StdEncoding.DecodeString(result.ReportXML)
+		if err != nil {
+			log.Warn().Err(err).Str("job_id", result.JobID).Msg("Failed to decode report XML, persisting metadata with an empty report")
+		} else {
+			xmlBytes = decoded
+		}
+	}
+
+	meta := reports.Metadata{
+		JobID:        result.JobID,
+		ProbeID:      result.ProbeID,
+		Target:       result.Target,
+		Status:       result.Status,
+		CompletedAt:  result.CompletedAt,
+		HostsScanned: result.Summary.HostsScanned,
+		VulnsHigh:    result.Summary.VulnsHigh,
+		VulnsMedium:  result.Summary.VulnsMedium,
+		VulnsLow:     result.Summary.VulnsLow,
+	}
+	if meta.CompletedAt.IsZero() {
+		meta.CompletedAt = time.Now()
+	}
+
+	if err := s.store.Put(context.Background(), meta, xmlBytes); err != nil {
+		log.Error().Err(err).Str("job_id", result.JobID).Msg("Failed to persist scan report")
+	}
+}
+
+// forwardResult encaminha resultado para sistema externo, com retry e
+// backoff exponencial. Se todas as tentativas falharem, o resultado vai para
+// a outbox em disco e é reenviado por runOutboxDrainLoop.
 func (s *WebhookServer) forwardResult(body []byte) {
+	timer := prometheus.NewTimer(s.metrics.ForwardLatencySeconds)
+	defer timer.ObserveDuration()
+
+	if err := forwardWithRetry(func() error { return s.postForward(body) }); err != nil {
+		log.Warn().Err(err).Str("url", s.forwardURL).Msg("Failed to forward result after retries, queueing to outbox")
+		if err := s.outbox.enqueue(body); err != nil {
+			log.Error().Err(err).Msg("Failed to enqueue result to outbox")
+		}
+	}
+}
+
+// postForward faz uma única tentativa de POST do resultado para forwardURL.
+func (s *WebhookServer) postForward(body []byte) error {
 	client := &http.Client{Timeout: 30 * time.Second}
 
-	resp, err := client.Post(s.forwardURL, "application/json", 
-		io.NopCloser(io.Reader(nil))) // TODO: usar body corretamente
+	resp, err := client.Post(s.forwardURL, "application/json", bytes.NewReader(body))
 	if err != nil {
-		log.Error().Err(err).Str("url", s.forwardURL).Msg("Failed to forward result")
-		return
+		return fmt.Errorf("webhook: failed to forward result: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode >= 400 {
-		log.Warn().
-			Str("url", s.forwardURL).
-			Int("status", resp.StatusCode).
-			Msg("Forward target returned error")
+		return fmt.Errorf("webhook: forward target returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// runOutboxDrainLoop tenta periodicamente reenviar os resultados que ficaram
+// presos na outbox após esgotarem as tentativas de forwardResult.
+func (s *WebhookServer) runOutboxDrainLoop() {
+	ticker := time.NewTicker(outboxDrainInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.outbox.drain(s.postForward)
 	}
 }
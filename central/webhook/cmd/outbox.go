@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	forwardMaxAttempts  = 3
+	forwardBaseDelay    = 1 * time.Second
+	outboxDrainInterval = 30 * time.Second
+)
+
+// outbox persiste em disco os resultados que esgotaram as tentativas de
+// forwardResult, para que um FORWARD_URL brevemente fora do ar não perca
+// resultados: runOutboxDrainLoop tenta reenviá-los periodicamente.
+type outbox struct {
+	dir string
+}
+
+func newOutbox(dir string) (*outbox, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("outbox: failed to create dir %s: %w", dir, err)
+	}
+	return &outbox{dir: dir}, nil
+}
+
+// enqueue grava body em um novo arquivo da outbox, nomeado para ordenar por
+// tempo de chegada.
+func (o *outbox) enqueue(body []byte) error {
+	name := fmt.Sprintf("%d.json", time.Now().UnixNano())
+	return os.WriteFile(filepath.Join(o.dir, name), body, 0644)
+}
+
+// drain tenta reenviar cada item pendente via send, removendo-o da outbox em
+// caso de sucesso e deixando-o para a próxima rodada em caso de falha.
+func (o *outbox) drain(send func([]byte) error) {
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		log.Warn().Err(err).Str("dir", o.dir).Msg("Failed to list outbox")
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(o.dir, entry.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to read outbox entry")
+			continue
+		}
+
+		if err := send(body); err != nil {
+			log.Warn().Err(err).Str("job_id", unmarshalJSONField(body, "job_id")).Msg("Outbox entry still failing to forward")
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("Failed to remove forwarded outbox entry")
+		}
+	}
+}
+
+// forwardWithRetry tenta send com backoff exponencial limitado a
+// forwardMaxAttempts tentativas, usado pelo caminho direto de forwardResult.
+// O drain da outbox não passa por aqui: faz uma única tentativa por item a
+// cada rodada.
+func forwardWithRetry(send func() error) error {
+	var err error
+	for attempt := 0; attempt < forwardMaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := time.Duration(float64(forwardBaseDelay) * math.Pow(2, float64(attempt-1)))
+			time.Sleep(delay)
+		}
+		if err = send(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// unmarshalJSONField é um pequeno helper para a outbox reportar o job_id nos
+// logs sem precisar do schema completo de ScanResult.
+func unmarshalJSONField(body []byte, field string) string {
+	var generic map[string]json.RawMessage
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return ""
+	}
+	var value string
+	json.Unmarshal(generic[field], &value)
+	return value
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutbox_EnqueueAndDrainSuccess(t *testing.T) {
+	o, err := newOutbox(t.TempDir())
+	if err != nil {
+		t.Fatalf("newOutbox: %v", err)
+	}
+
+	if err := o.enqueue([]byte(`{"job_id":"job-1"}`)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	var sent [][]byte
+	o.drain(func(body []byte) error {
+		sent = append(sent, body)
+		return nil
+	})
+
+	if len(sent) != 1 {
+		t.Fatalf("expected 1 entry drained, got %d", len(sent))
+	}
+
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected outbox to be empty after a successful drain, got %d entries", len(entries))
+	}
+}
+
+func TestOutbox_DrainLeavesEntryOnFailure(t *testing.T) {
+	o, err := newOutbox(t.TempDir())
+	if err != nil {
+		t.Fatalf("newOutbox: %v", err)
+	}
+
+	if err := o.enqueue([]byte(`{"job_id":"job-1"}`)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	o.drain(func([]byte) error { return errors.New("still down") })
+
+	entries, err := os.ReadDir(o.dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the failed entry to remain in the outbox, got %d entries", len(entries))
+	}
+}
+
+func TestForwardWithRetry_SucceedsAfterFailures(t *testing.T) {
+	attempts := 0
+	err := forwardWithRetry(func() error {
+		attempts++
+		if attempts < forwardMaxAttempts {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if attempts != forwardMaxAttempts {
+		t.Fatalf("expected %d attempts, got %d", forwardMaxAttempts, attempts)
+	}
+}
+
+func TestForwardWithRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	err := forwardWithRetry(func() error {
+		attempts++
+		return errors.New("permanent failure")
+	})
+	if err == nil {
+		t.Fatalf("expected an error after exhausting attempts")
+	}
+	if attempts != forwardMaxAttempts {
+		t.Fatalf("expected %d attempts, got %d", forwardMaxAttempts, attempts)
+	}
+}
+
+func TestUnmarshalJSONField(t *testing.T) {
+	body := []byte(`{"job_id":"job-1","status":"completed"}`)
+	if got := unmarshalJSONField(body, "job_id"); got != "job-1" {
+		t.Fatalf("expected job-1, got %q", got)
+	}
+	if got := unmarshalJSONField(body, "missing"); got != "" {
+		t.Fatalf("expected empty string for missing field, got %q", got)
+	}
+	if got := unmarshalJSONField([]byte("not json"), "job_id"); got != "" {
+		t.Fatalf("expected empty string for invalid JSON, got %q", got)
+	}
+}
+
+func TestOutbox_EnqueueWritesUnderDir(t *testing.T) {
+	dir := t.TempDir()
+	o, err := newOutbox(dir)
+	if err != nil {
+		t.Fatalf("newOutbox: %v", err)
+	}
+	if err := o.enqueue([]byte(`{}`)); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 file in %s, got %d", dir, len(entries))
+	}
+	if filepath.Ext(entries[0].Name()) != ".json" {
+		t.Fatalf("expected a .json file, got %q", entries[0].Name())
+	}
+}
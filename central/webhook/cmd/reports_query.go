@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/reports"
+)
+
+const (
+	defaultStatsWindow = 24 * time.Hour
+	defaultTopN        = 10
+)
+
+// handleReportsGet responde ao request/reply reports.get com os metadados e o
+// XML (base64) de um relatório, usado pela API para expor
+// GET /api/v1/scans/{jobID}/report.
+func (s *WebhookServer) handleReportsGet(msg *nats.Msg) {
+	var req struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.Unmarshal(msg.Data, &req); err != nil || req.JobID == "" {
+		respondError(msg, "bad_request", "job_id is required")
+		return
+	}
+
+	meta, xmlBytes, err := s.store.Get(context.Background(), req.JobID)
+	if err != nil {
+		switch err {
+		case reports.ErrNotFound:
+			respondError(msg, "not_found", "no report with that job_id")
+		case reports.ErrInvalidJobID:
+			respondError(msg, "bad_request", "job_id is not a valid identifier")
+		default:
+			log.Error().Err(err).Str("job_id", req.JobID).Msg("Failed to read report")
+			respondError(msg, "internal_error", "failed to read report")
+		}
+		return
+	}
+
+	data, err := json.Marshal(struct {
+		Success   bool             `json:"success"`
+		Meta      reports.Metadata `json:"meta"`
+		ReportXML string           `json:"report_xml"` // base64 encoded
+	}{Success: true, Meta: meta, ReportXML: base64.StdEncoding.EncodeToString(xmlBytes)})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal report response")
+		respondError(msg, "internal_error", "failed to marshal report")
+		return
+	}
+	msg.Respond(data)
+}
+
+// handleReportsList responde ao request/reply reports.list com uma listagem
+// paginada de relatórios, usado pela API para expor GET /api/v1/scans.
+func (s *WebhookServer) handleReportsList(msg *nats.Msg) {
+	var req struct {
+		Since  time.Time `json:"since"`
+		Status string    `json:"status"`
+		Limit  int       `json:"limit"`
+		Offset int       `json:"offset"`
+	}
+	json.Unmarshal(msg.Data, &req) // filtros são opcionais
+
+	if req.Limit <= 0 || req.Limit > 200 {
+		req.Limit = 50
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	all, err := s.store.List(context.Background(), reports.ListFilter{Since: req.Since, Status: req.Status})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list reports")
+		respondError(msg, "internal_error", "failed to list reports")
+		return
+	}
+
+	total := len(all)
+	page := paginate(all, req.Offset, req.Limit)
+
+	data, err := json.Marshal(struct {
+		Success bool               `json:"success"`
+		Total   int                `json:"total"`
+		Reports []reports.Metadata `json:"reports"`
+	}{Success: true, Total: total, Reports: page})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal report list response")
+		respondError(msg, "internal_error", "failed to marshal report list")
+		return
+	}
+	msg.Respond(data)
+}
+
+// handleReportsStats responde ao request/reply reports.stats com agregados de
+// telemetria sobre a janela pedida ("24h" ou "7d"), usado pela API para expor
+// GET /api/v1/stats.
+func (s *WebhookServer) handleReportsStats(msg *nats.Msg) {
+	var req struct {
+		Window string `json:"window"`
+	}
+	json.Unmarshal(msg.Data, &req) // window é opcional, default 24h
+
+	window := defaultStatsWindow
+	if req.Window == "7d" {
+		window = 7 * 24 * time.Hour
+	}
+
+	items, err := s.store.List(context.Background(), reports.ListFilter{Since: time.Now().Add(-window)})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list reports for stats")
+		respondError(msg, "internal_error", "failed to compute stats")
+		return
+	}
+
+	stats := reports.Summarize(items, defaultTopN)
+
+	data, err := json.Marshal(struct {
+		Success bool          `json:"success"`
+		Stats   reports.Stats `json:"stats"`
+	}{Success: true, Stats: stats})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to marshal stats response")
+		respondError(msg, "internal_error", "failed to marshal stats")
+		return
+	}
+	msg.Respond(data)
+}
+
+// paginate corta items em [offset, offset+limit), tolerando offset/limit fora
+// de faixa.
+func paginate(items []reports.Metadata, offset, limit int) []reports.Metadata {
+	if offset >= len(items) {
+		return []reports.Metadata{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// respondError envia o envelope {success:false, code, message} de resposta,
+// compartilhado pelos handlers de request/reply do webhook.
+func respondError(msg *nats.Msg, code, message string) {
+	data, _ := json.Marshal(struct {
+		Success bool   `json:"success"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}{Success: false, Code: code, Message: message})
+	msg.Respond(data)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/reports"
+)
+
+func TestPaginate(t *testing.T) {
+	items := []reports.Metadata{{JobID: "a"}, {JobID: "b"}, {JobID: "c"}}
+
+	cases := []struct {
+		name          string
+		offset, limit int
+		wantJobIDs    []string
+	}{
+		{"middle page", 1, 1, []string{"b"}},
+		{"limit beyond length", 0, 10, []string{"a", "b", "c"}},
+		{"offset beyond length", 10, 10, nil},
+		{"negative offset clamped by caller, treated as 0 here", 0, 2, []string{"a", "b"}},
+	}
+
+	for _, c := range cases {
+		got := paginate(items, c.offset, c.limit)
+		if len(got) != len(c.wantJobIDs) {
+			t.Errorf("%s: expected %d items, got %d", c.name, len(c.wantJobIDs), len(got))
+			continue
+		}
+		for i, want := range c.wantJobIDs {
+			if got[i].JobID != want {
+				t.Errorf("%s: item %d: expected %q, got %q", c.name, i, want, got[i].JobID)
+			}
+		}
+	}
+}
+
+func TestPaginate_NegativeOffsetPanics(t *testing.T) {
+	items := []reports.Metadata{{JobID: "a"}, {JobID: "b"}}
+
+	// paginate itself does not clamp negative offsets — it's handleReportsList
+	// that clamps Offset >= 0 before ever calling paginate. This documents
+	// that split: paginate is called directly here, so the test only starts
+	// failing (no more panic) if paginate itself begins clamping; it does not
+	// notice the caller-side clamp being removed.
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected paginate to panic on a negative offset")
+		}
+	}()
+
+	paginate(items, -5, 10)
+}
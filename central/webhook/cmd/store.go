@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/EduardoVieiraCardoso/greenbone-distributed/central/pkg/reports"
+)
+
+// newReportsStore constrói o reports.Store configurado via env vars.
+// REPORTS_BACKEND escolhe o backend ("fs", o default, ou "s3"); os demais
+// parâmetros dependem do backend escolhido.
+func newReportsStore() (reports.Store, error) {
+	switch backend := os.Getenv("REPORTS_BACKEND"); backend {
+	case "", "fs":
+		baseDir := os.Getenv("REPORTS_DIR")
+		if baseDir == "" {
+			baseDir = "./data"
+		}
+		return reports.NewFileStore(baseDir)
+
+	case "s3":
+		bucket := os.Getenv("REPORTS_S3_BUCKET")
+		endpoint := os.Getenv("REPORTS_S3_ENDPOINT") // vazio usa a resolução padrão da AWS; aponte para um MinIO caso contrário
+		indexPath := os.Getenv("REPORTS_INDEX_PATH")
+		if indexPath == "" {
+			indexPath = "./data/index.db"
+		}
+		if err := os.MkdirAll(filepath.Dir(indexPath), 0755); err != nil {
+			return nil, fmt.Errorf("reports: failed to create index dir: %w", err)
+		}
+		return reports.NewS3Store(context.Background(), bucket, endpoint, indexPath)
+
+	default:
+		return nil, fmt.Errorf("reports: unknown REPORTS_BACKEND %q", backend)
+	}
+}